@@ -1,51 +1,179 @@
 package textsel
 
-import (
-	"strings"
-)
+import "github.com/rivo/uniseg"
 
 // Retrieves the current line the cursor is on.
 func (ts *TextSel) getCurrentLine() string {
-	text := ts.text
-	buf := strings.Builder{}
-	row := 0
+	return ts.getLine(ts.cursorRow)
+}
+
+// Retrieves the line at the given row, including its trailing newline.
+func (ts *TextSel) getLine(targetRow int) string {
+	lines := ts.getLines()
+
+	if targetRow < 0 || targetRow >= len(lines) {
+		return ""
+	}
+
+	return lines[targetRow]
+}
+
+// Returns the row index (zero-based) the last line in the text.
+func (ts *TextSel) lastRow() int {
+	return len(ts.getLines()) - 1
+}
+
+// Returns all lines of the text with format codes removed. Each line includes
+// its line ending, except for the last one. The lines are cached until the
+// text changes and must not be modified.
+func (ts *TextSel) getLines() []string {
+	if ts.lines == nil {
+		// Remove any tags from the text and unescape escaped ones
+		ts.strippedText = stripTags(ts.text)
+		ts.lines = splitLines(ts.strippedText)
+	}
+
+	return ts.lines
+}
+
+// Splits text into lines, each including its line ending. Lines may end with
+// "\n", "\r\n" or a lone "\r", all of which tview displays as line breaks. A
+// trailing line ending does not start another line.
+func splitLines(text string) []string {
+	lines := []string{}
+	start := 0
 
 	for idx := 0; idx < len(text); idx++ {
-		// Skip any format codes
-		for formatRegex.MatchString(text[idx:]) {
-			match := formatRegex.FindString(text[idx:])
-			idx += len(match)
+		switch text[idx] {
+		case '\r':
+			if idx+1 < len(text) && text[idx+1] == '\n' {
+				idx++
+			}
+		case '\n':
+		default:
+			continue
 		}
 
-		char := text[idx]
+		lines = append(lines, text[start:idx+1])
+		start = idx + 1
+	}
+
+	if start < len(text) || len(lines) == 0 {
+		lines = append(lines, text[start:])
+	}
+
+	return lines
+}
 
-		if row == ts.cursorRow {
-			buf.WriteString(string(char))
+// Returns true if a grapheme cluster is a line ending. Each line ending,
+// including "\r\n", is a single grapheme cluster and occupies one column.
+func isLineEnding(cluster string) bool {
+	return cluster == "\n" || cluster == "\r\n" || cluster == "\r"
+}
+
+// Returns all lines of the text with format codes removed, each split into
+// its grapheme clusters (see graphemes). Like the lines, they are cached until
+// the text changes and must not be modified.
+func (ts *TextSel) getGraphemeLines() [][]string {
+	if ts.graphemeLines == nil {
+		lines := ts.getLines()
+		ts.graphemeLines = make([][]string, len(lines))
+
+		for row, line := range lines {
+			ts.graphemeLines[row] = graphemes(line)
 		}
+	}
 
-		if char == '\n' {
-			row++
+	return ts.graphemeLines
+}
 
-			if row > ts.cursorRow {
-				break
-			}
+// Splits a string into its grapheme clusters, i.e. the user-perceived
+// characters. Columns are counted in grapheme clusters, so that the cursor
+// never ends up in the middle of a multi-byte character, a character with
+// combining marks or an emoji sequence.
+func graphemes(s string) []string {
+	clusters := []string{}
+	state := -1
+
+	for s != "" {
+		var cluster string
+		cluster, s, _, state = uniseg.FirstGraphemeClusterInString(s, state)
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters
+}
+
+// Returns the number of columns (grapheme clusters) in a line.
+func lineLength(line string) int {
+	return uniseg.GraphemeClusterCount(line)
+}
+
+// Returns the byte offset of the given column in a line. Columns beyond the
+// end of the line map to its length.
+func columnOffset(line string, col int) int {
+	offset := 0
+	state := -1
+
+	for c := 0; c < col && offset < len(line); c++ {
+		var cluster string
+		cluster, _, _, state = uniseg.FirstGraphemeClusterInString(line[offset:], state)
+		offset += len(cluster)
+	}
+
+	return offset
+}
+
+// Returns the column of the grapheme cluster containing the given byte offset
+// in a line.
+func offsetColumn(line string, offset int) int {
+	col := 0
+	pos := 0
+	state := -1
+
+	for pos < len(line) {
+		var cluster string
+		cluster, _, _, state = uniseg.FirstGraphemeClusterInString(line[pos:], state)
+		pos += len(cluster)
+
+		if pos > offset {
+			break
 		}
+
+		col++
 	}
 
-	return buf.String()
+	return col
 }
 
-// Returns the row index (zero-based) the last line in the text.
-func (ts *TextSel) lastRow() int {
-	text := ts.text
-	lastIndex := len(text) - 1
-	count := 0
+// Returns the byte offset in the text returned by GetText(true) of the given
+// position.
+func (ts *TextSel) offsetOf(row int, col int) int {
+	offset := 0
 
-	for idx := 0; idx < len(text); idx++ {
-		if text[idx] == '\n' && idx != lastIndex {
-			count++
+	for r, line := range ts.getLines() {
+		if r == row {
+			return offset + columnOffset(line, col)
 		}
+
+		offset += len(line)
+	}
+
+	return offset
+}
+
+// Returns the position of the given byte offset in the text returned by
+// GetText(true).
+func (ts *TextSel) positionOf(offset int) (int, int) {
+	lines := ts.getLines()
+
+	for row, line := range lines {
+		if offset < len(line) || row == len(lines)-1 {
+			return row, offsetColumn(line, offset)
+		}
+
+		offset -= len(line)
 	}
 
-	return count
+	return 0, 0
 }
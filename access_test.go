@@ -33,3 +33,91 @@ func TestLastRow(t *testing.T) {
 		t.Error("lastRow() failed")
 	}
 }
+
+func TestGraphemes(t *testing.T) {
+	// "e" followed by a combining acute accent, and a family emoji made of
+	// several code points joined with zero-width joiners
+	line := "aé👨‍👩‍👧b\n"
+
+	expected := []string{"a", "é", "👨‍👩‍👧", "b", "\n"}
+	got := graphemes(line)
+
+	if len(got) != len(expected) {
+		t.Fatalf("graphemes() failed. Expected %q, got %q", expected, got)
+	}
+
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("graphemes() failed. Expected %q, got %q", expected, got)
+			break
+		}
+	}
+
+	if lineLength(line) != 5 {
+		t.Errorf("lineLength() failed. Expected 5, got %d", lineLength(line))
+	}
+
+	if offset := columnOffset(line, 3); offset != len("aé👨‍👩‍👧") {
+		t.Errorf("columnOffset() failed. Expected %d, got %d", len("aé👨‍👩‍👧"), offset)
+	}
+
+	if col := offsetColumn(line, 5); col != 2 {
+		t.Errorf("offsetColumn() failed. Expected 2, got %d", col)
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	cases := map[string][]string{
+		"":                {""},
+		"a\nb":            {"a\n", "b"},
+		"a\r\nb\r\n":      {"a\r\n", "b\r\n"},
+		"a\rb\r\n\nc":     {"a\r", "b\r\n", "\n", "c"},
+		"a\r\n\r\n[red]x": {"a\r\n", "\r\n", "[red]x"},
+	}
+
+	for text, expected := range cases {
+		got := splitLines(text)
+
+		if len(got) != len(expected) {
+			t.Errorf("splitLines(%q) failed. Expected %q, got %q", text, expected, got)
+			continue
+		}
+
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Errorf("splitLines(%q) failed. Expected %q, got %q", text, expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestLastRowLineEndings(t *testing.T) {
+	ts := NewTextSel().SetText("Hello\r\nWorld\rFoo\r\n[red]")
+
+	if ts.lastRow() != 2 {
+		t.Errorf("lastRow() failed with CRLF and CR line endings. Expected 2, got %d", ts.lastRow())
+	}
+}
+
+func TestLinesFollowText(t *testing.T) {
+	ts := NewTextSel().SetText("Hello\nWorld")
+
+	if got := ts.getGraphemeLines()[1]; len(got) != 5 {
+		t.Errorf("getGraphemeLines() failed. Expected 5 clusters, got %d", len(got))
+	}
+
+	ts.AppendText("!\nFoo")
+
+	if ts.lastRow() != 2 {
+		t.Errorf("lastRow() failed after AppendText. Expected 2, got %d", ts.lastRow())
+	}
+
+	if got := ts.getGraphemeLines()[1]; len(got) != 7 {
+		t.Errorf("getGraphemeLines() failed after AppendText. Expected 7 clusters, got %d", len(got))
+	}
+
+	if got := ts.GetText(true); got != "Hello\nWorld!\nFoo" {
+		t.Errorf("GetText(true) failed after AppendText. Expected %q, got %q", "Hello\nWorld!\nFoo", got)
+	}
+}
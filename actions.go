@@ -0,0 +1,271 @@
+package textsel
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Names of the built-in actions.
+const (
+	ActionMoveUp              = "move-up"
+	ActionMoveDown            = "move-down"
+	ActionMoveLeft            = "move-left"
+	ActionMoveRight           = "move-right"
+	ActionMoveToStartOfLine   = "move-to-start-of-line"
+	ActionMoveToEndOfLine     = "move-to-end-of-line"
+	ActionMoveToFirstLine     = "move-to-first-line"
+	ActionMoveToLastLine      = "move-to-last-line"
+	ActionMoveWordForward     = "move-word-forward"
+	ActionMoveWordBackward    = "move-word-backward"
+	ActionMoveWordEnd         = "move-word-end"
+	ActionMovePageDown        = "move-page-down"
+	ActionMovePageUp          = "move-page-up"
+	ActionMoveHalfPageDown    = "move-half-page-down"
+	ActionMoveHalfPageUp      = "move-half-page-up"
+	ActionScrollDown          = "scroll-down"
+	ActionScrollUp            = "scroll-up"
+	ActionStartSelection      = "start-selection"
+	ActionStartLineSelection  = "start-line-selection"
+	ActionStartBlockSelection = "start-block-selection"
+	ActionFinishSelection     = "finish-selection"
+	ActionResetSelection      = "reset-selection"
+	ActionPipeSelection       = "pipe-selection"
+	ActionStartSearch         = "start-search"
+	ActionSearchNext          = "search-next"
+	ActionSearchPrev          = "search-prev"
+	ActionYank                = "yank"
+	ActionYankLine            = "yank-line"
+	ActionCancel              = "cancel"
+	ActionShowHelp            = "show-help"
+	ActionSelectInnerWord     = "select-inner-word"
+	ActionNextRegion          = "next-region"
+	ActionPrevRegion          = "prev-region"
+	ActionSelectRegion        = "select-region"
+	ActionNextCodeBlock       = "next-code-block"
+	ActionPrevCodeBlock       = "prev-code-block"
+	ActionSelectCodeBlock     = "select-code-block"
+	ActionSelectCodeBlockAll  = "select-code-block-with-fences"
+	ActionNextBlock           = "next-block"
+	ActionPrevBlock           = "prev-block"
+	ActionSelectBlock         = "select-block"
+)
+
+// Motion describes whether an action is a motion, i.e. whether it can follow
+// a pending operator such as yank, and if so, whether the character the
+// motion ends on is included in the text the operator applies to, or whether
+// the operator applies to whole lines, as for vertical motions like `y j`.
+type Motion int
+
+const (
+	MotionNone Motion = iota
+	MotionInclusive
+	MotionExclusive
+	MotionLinewise
+)
+
+// Action is a named behavior of a TextSel. Actions can be bound to keys with a
+// Keymap or run directly with Execute.
+type Action struct {
+	// The name the action is bound and executed by
+	Name string
+
+	// A short, human-readable description of what the action does
+	Description string
+
+	// The implementation. The count is the number of times the action should
+	// be repeated (always at least 1); actions for which repetition makes no
+	// sense may ignore it.
+	Func func(ts *TextSel, count int) error
+
+	// Whether the action is a motion that a pending operator applies to
+	Motion Motion
+}
+
+// Returns an action function that calls f count times.
+func repeat(f func(ts *TextSel)) func(ts *TextSel, count int) error {
+	return func(ts *TextSel, count int) error {
+		for i := 0; i < count; i++ {
+			f(ts)
+		}
+
+		return nil
+	}
+}
+
+// Returns an action function that repeats a search count times. It fails if
+// there is no match.
+func repeatSearch(f func(ts *TextSel) bool) func(ts *TextSel, count int) error {
+	return func(ts *TextSel, count int) error {
+		for i := 0; i < count; i++ {
+			if f(ts) {
+				continue
+			}
+
+			if query := ts.GetSearchQuery(); query != "" {
+				return fmt.Errorf("pattern not found: %s", query)
+			}
+
+			return errors.New("no previous search")
+		}
+
+		return nil
+	}
+}
+
+// Returns an action function that calls f once, ignoring the count.
+func once(f func(ts *TextSel)) func(ts *TextSel, count int) error {
+	return func(ts *TextSel, count int) error {
+		f(ts)
+		return nil
+	}
+}
+
+// The built-in actions that every TextSel starts with.
+var builtinActions = []Action{
+	{ActionMoveUp, "Move the cursor up", repeat(func(ts *TextSel) { ts.MoveUp() }), MotionLinewise},
+	{ActionMoveDown, "Move the cursor down", repeat(func(ts *TextSel) { ts.MoveDown() }), MotionLinewise},
+	{ActionMoveLeft, "Move the cursor left", repeat(func(ts *TextSel) { ts.MoveLeft() }), MotionExclusive},
+	{ActionMoveRight, "Move the cursor right", repeat(func(ts *TextSel) { ts.MoveRight() }), MotionExclusive},
+	{ActionMoveToStartOfLine, "Move to the start of the line", once(func(ts *TextSel) { ts.MoveToStartOfLine() }), MotionExclusive},
+	{ActionMoveToEndOfLine, "Move to the end of the line", once(func(ts *TextSel) { ts.MoveToEndOfLine() }), MotionInclusive},
+	{ActionMoveToFirstLine, "Move to the first line", once(func(ts *TextSel) { ts.MoveToFirstLine() }), MotionLinewise},
+	{ActionMoveToLastLine, "Move to the last line", once(func(ts *TextSel) { ts.MoveToLastLine() }), MotionLinewise},
+	{ActionMoveWordForward, "Move to the start of the next word", repeat(func(ts *TextSel) { ts.MoveWordForward() }), MotionExclusive},
+	{ActionMoveWordBackward, "Move to the start of the previous word", repeat(func(ts *TextSel) { ts.MoveWordBackward() }), MotionExclusive},
+	{ActionMoveWordEnd, "Move to the end of the word", repeat(func(ts *TextSel) { ts.MoveWordEnd() }), MotionInclusive},
+	{ActionMovePageDown, "Move down one page", repeat(func(ts *TextSel) { ts.MovePageDown() }), MotionLinewise},
+	{ActionMovePageUp, "Move up one page", repeat(func(ts *TextSel) { ts.MovePageUp() }), MotionLinewise},
+	{ActionMoveHalfPageDown, "Move down half a page", repeat(func(ts *TextSel) { ts.MoveHalfPageDown() }), MotionLinewise},
+	{ActionMoveHalfPageUp, "Move up half a page", repeat(func(ts *TextSel) { ts.MoveHalfPageUp() }), MotionLinewise},
+	{ActionScrollDown, "Scroll the view down one line", repeat(func(ts *TextSel) { ts.scrollBy(1) }), MotionNone},
+	{ActionScrollUp, "Scroll the view up one line", repeat(func(ts *TextSel) { ts.scrollBy(-1) }), MotionNone},
+	{ActionStartSelection, "Start selecting", once(func(ts *TextSel) { ts.StartSelection() }), MotionNone},
+	{ActionFinishSelection, "Finish the selection", once(func(ts *TextSel) { ts.FinishSelection() }), MotionNone},
+	{ActionStartLineSelection, "Start selecting lines", once(func(ts *TextSel) { ts.StartLineSelection() }), MotionNone},
+	{ActionStartBlockSelection, "Start selecting a block", once(func(ts *TextSel) { ts.StartBlockSelection() }), MotionNone},
+	{ActionResetSelection, "Cancel the selection", once(func(ts *TextSel) { ts.ResetSelection() }), MotionNone},
+	{ActionPipeSelection, "Pipe the selection to the pipe command", func(ts *TextSel, count int) error {
+		if ts.pipeCommand == nil {
+			return errors.New("no pipe command set")
+		}
+
+		ts.PipeSelection(ts.pipeCommand)
+		return nil
+	}, MotionNone},
+	{ActionSelectInnerWord, "Select the word under the cursor", once(func(ts *TextSel) { ts.SelectInnerWord() }), MotionInclusive},
+	{ActionNextRegion, "Move to the next region", repeat(func(ts *TextSel) { ts.NextRegion() }), MotionExclusive},
+	{ActionPrevRegion, "Move to the previous region", repeat(func(ts *TextSel) { ts.PrevRegion() }), MotionExclusive},
+	{ActionSelectRegion, "Select the region under the cursor", once(func(ts *TextSel) { ts.SelectRegionAtCursor() }), MotionInclusive},
+	{ActionNextCodeBlock, "Move to the next code block", repeat(func(ts *TextSel) { ts.NextCodeBlock() }), MotionExclusive},
+	{ActionPrevCodeBlock, "Move to the previous code block", repeat(func(ts *TextSel) { ts.PrevCodeBlock() }), MotionExclusive},
+	{ActionSelectCodeBlock, "Select the contents of the code block", once(func(ts *TextSel) { ts.SelectCodeBlock() }), MotionInclusive},
+	{ActionSelectCodeBlockAll, "Select the code block with its fences", once(func(ts *TextSel) { ts.SelectCodeBlockWithFences() }), MotionInclusive},
+	{ActionNextBlock, "Move to the next block", repeat(func(ts *TextSel) { ts.NextBlock() }), MotionExclusive},
+	{ActionPrevBlock, "Move to the previous block", repeat(func(ts *TextSel) { ts.PrevBlock() }), MotionExclusive},
+	{ActionSelectBlock, "Select the block under the cursor", once(func(ts *TextSel) { ts.SelectBlockAtCursor() }), MotionInclusive},
+	{ActionStartSearch, "Search forward", once(func(ts *TextSel) { ts.StartSearch() }), MotionNone},
+	{ActionSearchNext, "Move to the next search match", repeatSearch((*TextSel).SearchNext), MotionExclusive},
+	{ActionSearchPrev, "Move to the previous search match", repeatSearch((*TextSel).SearchPrev), MotionExclusive},
+	{ActionYank, "Yank the selection or the following motion", once(func(ts *TextSel) { ts.Yank() }), MotionNone},
+	{ActionYankLine, "Yank the current line", once(func(ts *TextSel) { ts.YankLine() }), MotionNone},
+	{ActionCancel, "Cancel the search, operator or selection", once(func(ts *TextSel) { ts.Cancel() }), MotionNone},
+	{ActionShowHelp, "Show the key bindings", func(ts *TextSel, count int) error {
+		if ts.helpFunc == nil {
+			return errors.New("no help function set")
+		}
+
+		ts.ShowHelp()
+		return nil
+	}, MotionNone},
+}
+
+// Returns a new registry containing the built-in actions.
+func newActionRegistry() map[string]Action {
+	actions := make(map[string]Action, len(builtinActions))
+
+	for _, action := range builtinActions {
+		actions[action.Name] = action
+	}
+
+	return actions
+}
+
+// RegisterAction adds an action to the widget's registry, or replaces the
+// existing action of the same name. Registered actions can be bound to keys
+// and executed just like the built-in ones.
+//
+// Example:
+//
+//	textSel.RegisterAction("copy-line", "Copy the current line", func(ts *textsel.TextSel, count int) error {
+//		ts.MoveToStartOfLine().StartSelection().MoveToEndOfLine().FinishSelection()
+//		return nil
+//	})
+//	textSel.GetKeymap().Bind("Y", "copy-line")
+func (ts *TextSel) RegisterAction(name string, description string, f func(ts *TextSel, count int) error) *TextSel {
+	ts.actions[name] = Action{Name: name, Description: description, Func: f}
+	return ts
+}
+
+// RegisterMotion is like RegisterAction, but registers an action that moves
+// the cursor and can therefore follow a pending operator.
+func (ts *TextSel) RegisterMotion(name string, description string, motion Motion, f func(ts *TextSel, count int) error) *TextSel {
+	ts.actions[name] = Action{Name: name, Description: description, Func: f, Motion: motion}
+	return ts
+}
+
+// GetAction returns the named action from the registry.
+func (ts *TextSel) GetAction(name string) (Action, bool) {
+	action, ok := ts.actions[name]
+	return action, ok
+}
+
+// GetActions returns all registered actions, sorted by name.
+func (ts *TextSel) GetActions() []Action {
+	actions := make([]Action, 0, len(ts.actions))
+
+	for _, action := range ts.actions {
+		actions = append(actions, action)
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].Name < actions[j].Name
+	})
+
+	return actions
+}
+
+// Execute runs the named action count times. A count less than 1 is treated
+// as 1. An error is returned if there is no action with that name or if the
+// action itself fails.
+//
+// Example:
+//
+//	if err := textSel.Execute(textsel.ActionMoveDown, 5); err != nil {
+//		log.Println(err)
+//	}
+func (ts *TextSel) Execute(name string, count int) error {
+	action, ok := ts.actions[name]
+	if !ok {
+		return fmt.Errorf("unknown action %q", name)
+	}
+
+	pending := ts.mode == ModePendingOperator
+	err := action.Func(ts, max(count, 1))
+
+	// A pending operator applies to the motion that follows it; anything else,
+	// including a motion that fails, cancels it.
+	if pending && ts.mode == ModePendingOperator {
+		if action.Motion != MotionNone && err == nil {
+			ts.applyOperator(action.Motion)
+		} else {
+			ts.cancelOperator()
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
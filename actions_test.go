@@ -0,0 +1,121 @@
+package textsel
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExecute(t *testing.T) {
+	ts := NewTextSel().SetText("Hello\nWorld\nFoo\nBar")
+
+	if err := ts.Execute(ActionMoveDown, 2); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if err := ts.Execute(ActionMoveRight, 0); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	row, col := ts.GetCursorPosition()
+	if row != 2 || col != 1 {
+		t.Errorf("Execute failed. Expected cursorRow = 2, cursorCol = 1, got = %d, %d", row, col)
+	}
+
+	err := ts.Execute("fly-to-the-moon", 1)
+	if err == nil || err.Error() != `unknown action "fly-to-the-moon"` {
+		t.Errorf("Execute of unknown action failed. Got error: %v", err)
+	}
+}
+
+func TestRegisterAction(t *testing.T) {
+	ts := NewTextSel().SetText("Hello\nWorld")
+
+	var selected string
+	ts.SetSelectFunc(func(text string) { selected = text })
+
+	ts.RegisterAction("copy-line", "Copy the current line", func(ts *TextSel, count int) error {
+		ts.MoveToStartOfLine().StartSelection().MoveToEndOfLine().FinishSelection()
+		return nil
+	})
+
+	ts.GetKeymap().Bind("Y", "copy-line")
+	ts.handleKeyEvents(runeKey('j'))
+	ts.handleKeyEvents(runeKey('Y'))
+
+	if selected != "World" {
+		t.Errorf("Registered action failed. Expected 'World', got: '%s'", selected)
+	}
+
+	action, ok := ts.GetAction("copy-line")
+	if !ok || action.Description != "Copy the current line" {
+		t.Errorf("GetAction failed. Got: %+v", action)
+	}
+}
+
+func TestActionErrors(t *testing.T) {
+	var reported error
+
+	ts := NewTextSel().SetErrorFunc(func(err error) { reported = err })
+	failure := errors.New("boom")
+
+	ts.RegisterAction("explode", "Fail", func(ts *TextSel, count int) error {
+		return failure
+	})
+
+	if err := ts.Execute("explode", 1); !errors.Is(err, failure) {
+		t.Errorf("Execute did not return the action's error. Got: %v", err)
+	}
+
+	ts.GetKeymap().Bind("x", "explode")
+	ts.handleKeyEvents(runeKey('x'))
+
+	if !errors.Is(reported, failure) {
+		t.Errorf("Error of key-triggered action was not reported. Got: %v", reported)
+	}
+}
+
+func TestFailedMotionCancelsOperator(t *testing.T) {
+	var selected []string
+	var reported error
+
+	ts := NewTextSel().SetKeymap(VimKeymap()).
+		SetText("hello world").
+		SetSelectFunc(func(text string) { selected = append(selected, text) }).
+		SetErrorFunc(func(err error) { reported = err })
+
+	// Without a previous search, and without a match
+	ts.Search("nope")
+
+	for _, r := range "yn" {
+		ts.handleKeyEvents(runeKey(r))
+	}
+
+	if len(selected) != 0 || ts.GetMode() != ModeNormal {
+		t.Errorf("Failed motion did not cancel the operator. Expected nothing yanked in normal mode, got %q in %s", selected, ts.GetMode())
+	}
+
+	if reported == nil || reported.Error() != "search-next: pattern not found: nope" {
+		t.Errorf("Failed motion was not reported. Got: %v", reported)
+	}
+}
+
+func TestGetActions(t *testing.T) {
+	ts := NewTextSel()
+	actions := ts.GetActions()
+
+	if len(actions) != len(builtinActions) {
+		t.Errorf("GetActions failed. Expected %d actions, got %d", len(builtinActions), len(actions))
+	}
+
+	for i := 1; i < len(actions); i++ {
+		if actions[i-1].Name >= actions[i].Name {
+			t.Errorf("GetActions is not sorted: '%s' before '%s'", actions[i-1].Name, actions[i].Name)
+		}
+	}
+
+	for _, action := range actions {
+		if action.Description == "" {
+			t.Errorf("Action '%s' has no description", action.Name)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package textsel
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/rivo/tview"
+)
+
+// Translates ANSI escape sequences into tview tags with tview's ANSI writer,
+// keeping track of where in the original text each displayed character came
+// from. Unlike `tview.TranslateANSI`, brackets in the text are escaped, so
+// they are displayed as they are.
+type ansiTranslator struct {
+	// The original text, and any incomplete UTF-8 sequence at its end that
+	// has not been translated yet
+	source  []byte
+	partial []byte
+
+	*sourceMap
+
+	ansi   io.Writer
+	output *bytes.Buffer
+
+	// The ranges of the escape sequences that changed the style, and whether
+	// they reset it completely
+	escapes []ansiEscape
+
+	// Start of the escape sequence being parsed, or -1
+	pending int
+
+	// Whether all of the displayed text was translated from the original
+	mapped bool
+}
+
+// An escape sequence in the original text.
+type ansiEscape struct {
+	start, end int
+	reset      bool
+}
+
+// Creates a translator. If mapped is false, the source of the text displayed
+// before the translated text is unknown.
+func newANSITranslator(mapped bool) *ansiTranslator {
+	output := &bytes.Buffer{}
+
+	return &ansiTranslator{
+		sourceMap: newSourceMap(),
+		ansi:      tview.ANSIWriter(output),
+		output:    output,
+		pending:   -1,
+		mapped:    mapped,
+	}
+}
+
+// Translates the next part of the original text and returns the tview text
+// to display for it. Incomplete UTF-8 sequences at the end are kept until the
+// next call, unless final is true.
+func (a *ansiTranslator) translate(p []byte, final bool) string {
+	data := append(a.partial, p...)
+	a.partial = nil
+
+	if !final {
+		// Keep an incomplete rune at the end for the next call
+		for n := 1; n < utf8.UTFMax && n <= len(data); n++ {
+			if utf8.RuneStart(data[len(data)-n]) {
+				if !utf8.FullRune(data[len(data)-n:]) {
+					a.partial = append([]byte{}, data[len(data)-n:]...)
+					data = data[:len(data)-n]
+				}
+
+				break
+			}
+		}
+	}
+
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		offset := len(a.source)
+		a.source = append(a.source, data[:size]...)
+		data = data[size:]
+
+		// Feed the writer one rune at a time. Text comes back unchanged;
+		// escape sequences produce nothing until they are complete.
+		a.output.Reset()
+		a.ansi.Write([]byte(string(r)))
+		out := a.output.String()
+
+		switch {
+		case out == "":
+			if a.pending < 0 {
+				a.pending = offset
+			}
+		case out == string(r) && a.pending < 0:
+			a.text(string(r), offset, len(a.source))
+		default:
+			start := offset
+			if a.pending >= 0 {
+				start = a.pending
+			}
+
+			a.pending = -1
+			a.escape(out, start, len(a.source))
+		}
+	}
+
+	return a.take()
+}
+
+// Writes the translation of the escape sequence in the given range of the
+// original text.
+func (a *ansiTranslator) escape(out string, start, end int) {
+	styled := false
+
+	for _, token := range splitTags(out) {
+		if token.isTag() {
+			a.tag(token.raw)
+			styled = true
+		} else {
+			// Some sequences move to the next line
+			a.text(token.char, start, end)
+		}
+	}
+
+	if styled {
+		a.escapes = append(a.escapes, ansiEscape{start, end, strings.HasPrefix(out, "[-:-:-]")})
+	}
+}
+
+// Returns the part of the original text that the given range of the
+// displayed text was translated from, with the escape sequences needed to
+// reproduce its style: those since the last reset before it, and those in the
+// original text between the ranges of a block selection, which are joined by
+// newlines. Returns false if the original text is not known.
+func (a *ansiTranslator) original(ranges [][2]int) (string, bool) {
+	if !a.mapped || !a.covers(ranges) {
+		return "", false
+	}
+
+	buf := strings.Builder{}
+
+	// Writes the escape sequences within the given range of the original
+	writeEscapes := func(from, to int) {
+		for _, e := range a.escapes {
+			if e.start >= from && e.end <= to {
+				buf.Write(a.source[e.start:e.end])
+			}
+		}
+	}
+
+	first := a.starts[ranges[0][0]]
+	from := 0
+
+	for _, e := range a.escapes {
+		if e.end <= first && e.reset {
+			from = e.start
+		}
+	}
+
+	writeEscapes(from, first)
+
+	for i, r := range ranges {
+		start, end := a.starts[r[0]], a.ends[r[1]-1]
+
+		if i > 0 {
+			buf.WriteString("\n")
+			writeEscapes(a.ends[ranges[i-1][1]-1], start)
+		}
+
+		buf.Write(a.source[start:end])
+	}
+
+	buf.WriteString("\x1b[0m")
+
+	return buf.String(), true
+}
+
+// SetANSIText sets the text content of the TextSel widget from text with ANSI
+// escape sequences, such as the output of a command line tool, translating
+// them into tview tags with `tview.ANSIWriter`. Unlike with
+// `tview.TranslateANSI`, brackets in the text are displayed as they are. The
+// original text is remembered, so that GetSelectedTextAs(FormatANSI) returns
+// the selected part of it with its escape sequences intact.
+//
+// Example:
+//
+//	output, _ := exec.Command("ls", "--color=always").Output()
+//	textSel.SetANSIText(string(output))
+func (ts *TextSel) SetANSIText(text string) *TextSel {
+	translator := newANSITranslator(true)
+	ts.SetText(translator.translate([]byte(text), true))
+	ts.source = translator
+
+	return ts
+}
+
+// ANSIWriter returns an `io.Writer` that appends text with ANSI escape
+// sequences to the widget, like SetANSIText, keeping the cursor and
+// selection. Escape sequences and UTF-8 characters may be split across
+// writes.
+//
+// If app is not nil, the writer may be used from any goroutine: writes are
+// buffered, and the buffered text is added to the widget on the
+// application's goroutine with `tview.Application.QueueUpdateDraw`, so that
+// the widget is rendered once for many small writes. If app is nil, each
+// write is added to the widget immediately, and the writer must only be used
+// from the goroutine that uses the widget.
+//
+// Example:
+//
+//	cmd := exec.Command("make")
+//	cmd.Stdout = textSel.ANSIWriter(app)
+func (ts *TextSel) ANSIWriter(app *tview.Application) io.Writer {
+	return &ansiTextWriter{ts: ts, app: app}
+}
+
+// The writer returned by ANSIWriter.
+type ansiTextWriter struct {
+	ts  *TextSel
+	app *tview.Application
+
+	// Text written but not yet added to the widget, and whether adding it has
+	// been queued
+	mutex   sync.Mutex
+	pending []byte
+	queued  bool
+}
+
+// Write buffers p and adds it to the widget's text, or queues adding it.
+func (w *ansiTextWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	w.pending = append(w.pending, p...)
+	queue := w.app != nil && !w.queued
+	w.queued = w.queued || queue
+	w.mutex.Unlock()
+
+	if w.app == nil {
+		w.flush()
+	} else if queue {
+		w.app.QueueUpdateDraw(w.flush)
+	}
+
+	return len(p), nil
+}
+
+// Translates the buffered text and appends it to the widget's text.
+func (w *ansiTextWriter) flush() {
+	w.mutex.Lock()
+	p := w.pending
+	w.pending = nil
+	w.queued = false
+	w.mutex.Unlock()
+
+	if len(p) == 0 {
+		return
+	}
+
+	ts := w.ts
+
+	translator, ok := ts.source.(*ansiTranslator)
+	if !ok {
+		translator = newANSITranslator(ts.text == "")
+		ts.source = translator
+	}
+
+	ts.storeText(ts.text + translator.translate(p, false))
+	ts.highlightCursor()
+}
@@ -0,0 +1,116 @@
+package textsel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func TestSetANSIText(t *testing.T) {
+	ts := NewTextSel().SetANSIText("\x1b[31mred\x1b[0m [INFO] \x1b[1mbold\x1b[0m\nnext")
+
+	if got := ts.GetText(true); got != "red [INFO] bold\nnext" {
+		t.Errorf("SetANSIText failed. Expected %q, got %q", "red [INFO] bold\nnext", got)
+	}
+
+	tests := []struct {
+		start, end [2]int
+		plain      string
+		ansi       string
+	}{
+		{[2]int{0, 11}, [2]int{0, 14}, "bold", "\x1b[0m\x1b[1mbold\x1b[0m"},
+		{[2]int{0, 1}, [2]int{0, 6}, "ed [IN", "\x1b[31med\x1b[0m [IN\x1b[0m"},
+		{[2]int{0, 14}, [2]int{1, 1}, "d\nne", "\x1b[0m\x1b[1md\x1b[0m\nne\x1b[0m"},
+	}
+
+	for _, test := range tests {
+		ts.ResetSelection()
+		ts.SetCursorPosition(test.start[0], test.start[1]).StartSelection()
+		ts.SetCursorPosition(test.end[0], test.end[1])
+
+		if got := ts.GetSelectedText(); got != test.plain {
+			t.Errorf("GetSelectedText failed. Expected %q, got %q", test.plain, got)
+		}
+
+		if got := ts.GetSelectedTextAs(FormatANSI); got != test.ansi {
+			t.Errorf("GetSelectedTextAs(FormatANSI) failed. Expected %q, got %q", test.ansi, got)
+		}
+	}
+}
+
+func TestANSIBlockSelection(t *testing.T) {
+	ts := NewTextSel().SetANSIText("\x1b[31mabc\x1b[32mdef\r\ngh\x1b[0mijkl")
+	ts.SetCursorPosition(0, 1).StartBlockSelection().SetCursorPosition(1, 2)
+
+	if got := ts.GetSelectedText(); got != "bc\nhi" {
+		t.Errorf("GetSelectedText failed. Expected %q, got %q", "bc\nhi", got)
+	}
+
+	expected := "\x1b[31mbc\n\x1b[32mh\x1b[0mi\x1b[0m"
+	if got := ts.GetSelectedTextAs(FormatANSI); got != expected {
+		t.Errorf("GetSelectedTextAs(FormatANSI) failed. Expected %q, got %q", expected, got)
+	}
+}
+
+func TestANSIWriter(t *testing.T) {
+	ts := NewTextSel()
+	w := ts.ANSIWriter(nil)
+
+	for _, part := range []string{"\x1b[3", "2mgr", "een\xe2\x9c", "\x93 [ok]\x1b[", "0m\n"} {
+		if n, err := w.Write([]byte(part)); n != len(part) || err != nil {
+			t.Errorf("Write failed. Expected %d, nil, got %d, %v", len(part), n, err)
+		}
+	}
+
+	if got := ts.GetText(true); got != "green✓ [ok]\n" {
+		t.Errorf("ANSIWriter failed. Expected %q, got %q", "green✓ [ok]\n", got)
+	}
+
+	ts.SetCursorPosition(0, 3).StartSelection().SetCursorPosition(0, 6)
+
+	expected := "\x1b[32men✓ \x1b[0m"
+	if got := ts.GetSelectedTextAs(FormatANSI); got != expected {
+		t.Errorf("GetSelectedTextAs(FormatANSI) failed. Expected %q, got %q", expected, got)
+	}
+
+	// Text that was not written as ANSI has no original
+	ts.SetText("[red]plain")
+	ts.ANSIWriter(nil).Write([]byte(" \x1b[1mmore"))
+	ts.StartSelection().MoveToEndOfLine()
+
+	expected = "\x1b[0;91mplain \x1b[0;91;1mmore\x1b[0m"
+	if got := ts.GetSelectedTextAs(FormatANSI); got != expected {
+		t.Errorf("GetSelectedTextAs(FormatANSI) failed. Expected %q, got %q", expected, got)
+	}
+}
+
+func TestANSIWriterQueuesUpdates(t *testing.T) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	ts := NewTextSel()
+	app := tview.NewApplication().SetScreen(screen).SetRoot(ts, true)
+
+	done := make(chan error)
+	go func() { done <- app.Run() }()
+
+	// Writes from another goroutine are added on the application's goroutine
+	w := ts.ANSIWriter(app)
+	for i := 0; i < 100; i++ {
+		w.Write([]byte("\x1b[32mline\x1b[0m\n"))
+	}
+
+	// QueueUpdate waits until the function has run after the queued writes
+	var got string
+	app.QueueUpdate(func() { got = ts.GetText(true) })
+
+	if got != strings.Repeat("line\n", 100) {
+		t.Errorf("ANSIWriter failed. Expected 100 lines, got %q", got)
+	}
+
+	app.Stop()
+
+	if err := <-done; err != nil {
+		t.Errorf("Running the application failed: %v", err)
+	}
+}
@@ -0,0 +1,337 @@
+package textsel
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/bidi"
+)
+
+// SetBidi enables support for bidirectional text. When enabled, lines that
+// contain right-to-left text (such as Hebrew or Arabic) are displayed in
+// visual order according to the Unicode bidirectional algorithm, and MoveLeft
+// and MoveRight move the cursor visually rather than logically. Each line is
+// a paragraph of its own, whose direction is that of its first strong
+// character. Explicit embeddings, overrides and isolates are ignored, bracket
+// pairs are not matched, and only common brackets are mirrored. Positions and
+// selections remain logical, so the selected text is always a contiguous part
+// of the original text, even if it is displayed in several pieces. The
+// default is false, which displays all text in logical order.
+//
+// Example:
+//
+//	textSel.SetBidi(true).SetText(multilingualText)
+func (ts *TextSel) SetBidi(enabled bool) *TextSel {
+	ts.bidi = enabled
+	ts.highlightCursor()
+	return ts
+}
+
+// Returns the logical columns of a line in visual order. The line ending is
+// always displayed last. Returns nil if bidi support is disabled or the line
+// contains no right-to-left text.
+func (ts *TextSel) visualOrder(line []string) []int {
+	order, _ := ts.bidiLayout(line)
+	return order
+}
+
+// Returns the logical columns of a line in visual order, and the embedding
+// level of each column, which is odd for columns displayed right to left.
+// The line ending is always displayed last, at the paragraph level. Returns
+// nil if bidi support is disabled or the line contains no right-to-left text.
+//
+// The levels are resolved with the implicit rules of the Unicode
+// bidirectional algorithm (UAX #9), treating the line as a paragraph of its
+// own: the paragraph level is that of the first strong character (P2, P3),
+// followed by the rules for weak types (W1-W7), neutrals (N1, N2), implicit
+// levels (I1, I2) and trailing whitespace (L1). Explicit embeddings,
+// overrides and isolates are treated as neutrals, and bracket pairs (N0) are
+// not matched.
+func (ts *TextSel) bidiLayout(line []string) ([]int, []int) {
+	if !ts.bidi {
+		return nil, nil
+	}
+
+	ending := len(line) > 0 && isLineEnding(line[len(line)-1])
+	if ending {
+		line = line[:len(line)-1]
+	}
+
+	if !hasRightToLeft(strings.Join(line, "")) {
+		return nil, nil
+	}
+
+	// Each column has the class of its first rune; combining marks are part
+	// of the cluster of their base character.
+	classes := make([]bidi.Class, len(line))
+	for col, char := range line {
+		r, _ := utf8.DecodeRuneInString(char)
+		props, _ := bidi.LookupRune(r)
+		classes[col] = props.Class()
+	}
+
+	paragraphLevel := paragraphLevel(classes)
+	levels := resolveLevels(classes, paragraphLevel)
+
+	order := make([]int, len(line))
+	for col := range order {
+		order[col] = col
+	}
+
+	// Reverse every sequence of columns at or above each level, from the
+	// highest level down to the lowest odd level (rule L2).
+	for level := slices.Max(levels); level >= 1; level-- {
+		for start := 0; start < len(order); start++ {
+			if levels[order[start]] < level {
+				continue
+			}
+
+			end := start
+			for end+1 < len(order) && levels[order[end+1]] >= level {
+				end++
+			}
+
+			for i, j := start, end; i < j; i, j = i+1, j-1 {
+				order[i], order[j] = order[j], order[i]
+			}
+
+			start = end
+		}
+	}
+
+	if ending {
+		order = append(order, len(line))
+		levels = append(levels, paragraphLevel)
+	}
+
+	return order, levels
+}
+
+// Returns the level of a paragraph: 1 if its first strong character is
+// right-to-left, or else 0 (rules P2 and P3).
+func paragraphLevel(classes []bidi.Class) int {
+	for _, class := range classes {
+		switch class {
+		case bidi.L:
+			return 0
+		case bidi.R, bidi.AL:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// Returns the embedding level of each character of a paragraph with the
+// given classes and level.
+func resolveLevels(classes []bidi.Class, paragraphLevel int) []int {
+	types := slices.Clone(classes)
+	n := len(types)
+
+	// The embedding direction, which is also the direction at the start and
+	// the end of the paragraph
+	embedding := bidi.L
+	if paragraphLevel%2 == 1 {
+		embedding = bidi.R
+	}
+
+	// W1: Non-spacing marks take the type of the previous character
+	prev := embedding
+	for i, t := range types {
+		if t == bidi.NSM {
+			types[i] = prev
+		} else {
+			prev = t
+		}
+	}
+
+	// W2, W3: European numbers after Arabic letters are Arabic numbers, and
+	// Arabic letters are right-to-left
+	strong := embedding
+	for i, t := range types {
+		switch t {
+		case bidi.L, bidi.R, bidi.AL:
+			strong = t
+		case bidi.EN:
+			if strong == bidi.AL {
+				types[i] = bidi.AN
+			}
+		}
+
+		if t == bidi.AL {
+			types[i] = bidi.R
+		}
+	}
+
+	// W4: A single separator between two numbers of the same type joins them
+	for i := 1; i < n-1; i++ {
+		before, after := types[i-1], types[i+1]
+
+		if types[i] == bidi.ES && before == bidi.EN && after == bidi.EN {
+			types[i] = bidi.EN
+		} else if types[i] == bidi.CS && before == after && (before == bidi.EN || before == bidi.AN) {
+			types[i] = before
+		}
+	}
+
+	// W5: Terminators next to European numbers are part of them
+	for start := 0; start < n; start++ {
+		if types[start] != bidi.ET {
+			continue
+		}
+
+		end := start
+		for end < n && types[end] == bidi.ET {
+			end++
+		}
+
+		if (start > 0 && types[start-1] == bidi.EN) || (end < n && types[end] == bidi.EN) {
+			for i := start; i < end; i++ {
+				types[i] = bidi.EN
+			}
+		}
+
+		start = end - 1
+	}
+
+	// W6, W7: Remaining separators and terminators are neutral, and European
+	// numbers in left-to-right text are left-to-right
+	strong = embedding
+	for i, t := range types {
+		switch t {
+		case bidi.ES, bidi.ET, bidi.CS:
+			types[i] = bidi.ON
+		case bidi.L, bidi.R:
+			strong = t
+		case bidi.EN:
+			if strong == bidi.L {
+				types[i] = bidi.L
+			}
+		}
+	}
+
+	// N1, N2: Neutrals between characters of the same direction take that
+	// direction, and the embedding direction otherwise. Numbers count as
+	// right-to-left.
+	direction := func(i int) bidi.Class {
+		switch {
+		case i < 0 || i >= n:
+			return embedding
+		case types[i] == bidi.L:
+			return bidi.L
+		default:
+			return bidi.R
+		}
+	}
+
+	for start := 0; start < n; start++ {
+		if isStrongOrNumber(types[start]) {
+			continue
+		}
+
+		end := start
+		for end < n && !isStrongOrNumber(types[end]) {
+			end++
+		}
+
+		resolved := embedding
+		if before, after := direction(start-1), direction(end); before == after {
+			resolved = before
+		}
+
+		for i := start; i < end; i++ {
+			types[i] = resolved
+		}
+
+		start = end - 1
+	}
+
+	// I1, I2: Characters that differ from the embedding direction are nested
+	// one level deeper, and numbers in left-to-right text two levels
+	levels := make([]int, n)
+	for i, t := range types {
+		levels[i] = paragraphLevel
+
+		switch {
+		case paragraphLevel%2 == 0 && t == bidi.R:
+			levels[i]++
+		case paragraphLevel%2 == 0 && (t == bidi.EN || t == bidi.AN):
+			levels[i] += 2
+		case paragraphLevel%2 == 1 && t != bidi.R:
+			levels[i]++
+		}
+	}
+
+	// L1: Segment separators, and whitespace before them and at the end of
+	// the line, are at the paragraph level
+	trailing := true
+	for i := n - 1; i >= 0; i-- {
+		switch classes[i] {
+		case bidi.S:
+			trailing = true
+			levels[i] = paragraphLevel
+		case bidi.WS:
+			if trailing {
+				levels[i] = paragraphLevel
+			}
+		default:
+			trailing = false
+		}
+	}
+
+	return levels
+}
+
+// Returns true if a resolved type is strong or a number, i.e. not neutral.
+func isStrongOrNumber(t bidi.Class) bool {
+	return t == bidi.L || t == bidi.R || t == bidi.EN || t == bidi.AN
+}
+
+// Mirrored forms of characters displayed right to left (rule L4), for
+// common brackets and comparison signs.
+var bidiMirrors = map[string]string{
+	"(": ")", ")": "(",
+	"[": "]", "]": "[",
+	"{": "}", "}": "{",
+	"<": ">", ">": "<",
+	"«": "»", "»": "«",
+	"‹": "›", "›": "‹",
+	"≤": "≥", "≥": "≤",
+	"⟨": "⟩", "⟩": "⟨",
+}
+
+// Returns the character as displayed at the given embedding level: mirrored
+// at odd levels, if it has a mirrored form.
+func mirrorAtLevel(char string, level int) string {
+	if level%2 == 1 {
+		if mirrored, ok := bidiMirrors[char]; ok {
+			return mirrored
+		}
+	}
+
+	return char
+}
+
+// Returns the visual position of a logical column in a line displayed in the
+// given order. Columns beyond the end of the line are treated as the last one.
+func visualPosition(order []int, col int) int {
+	for pos, c := range order {
+		if c == col {
+			return pos
+		}
+	}
+
+	return len(order) - 1
+}
+
+// Returns true if the text contains a strong right-to-left character.
+func hasRightToLeft(text string) bool {
+	for _, r := range text {
+		if props, _ := bidi.LookupRune(r); props.Class() == bidi.R || props.Class() == bidi.AL {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package textsel
+
+import (
+	"testing"
+
+	"github.com/rivo/tview"
+)
+
+func TestVisualOrder(t *testing.T) {
+	ts := NewTextSel().SetBidi(true)
+
+	cases := []struct {
+		text     string
+		expected []int
+	}{
+		{"abc\n", nil},
+		{"ab אבג\n", []int{0, 1, 2, 5, 4, 3, 6}},
+		{"אבג 12 דה", []int{8, 7, 6, 4, 5, 3, 2, 1, 0}},
+		{"a אב 12 גד b", []int{0, 1, 9, 8, 7, 5, 6, 4, 3, 2, 10, 11}},
+		// The paragraph direction is that of the first strong character,
+		// after leading digits and neutrals
+		{"123 שלום abc", []int{9, 10, 11, 8, 7, 6, 5, 4, 3, 0, 1, 2}},
+		{"- אב c", []int{5, 4, 3, 2, 1, 0}},
+		{"(12) abc אב", []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 9}},
+		// Tabs, and whitespace before them, separate the runs around them
+		{"a אב \tגד", []int{0, 1, 3, 2, 4, 5, 7, 6}},
+	}
+
+	for _, c := range cases {
+		got := ts.visualOrder(graphemes(c.text))
+
+		if len(got) != len(c.expected) {
+			t.Errorf("visualOrder(%q) failed. Expected %v, got %v", c.text, c.expected, got)
+			continue
+		}
+
+		for i := range c.expected {
+			if got[i] != c.expected[i] {
+				t.Errorf("visualOrder(%q) failed. Expected %v, got %v", c.text, c.expected, got)
+				break
+			}
+		}
+	}
+
+	if ts.SetBidi(false).visualOrder(graphemes("אבג")) != nil {
+		t.Error("visualOrder() reordered text with bidi support disabled")
+	}
+}
+
+func TestMoveVisually(t *testing.T) {
+	ts := NewTextSel().SetBidi(true).SetText("ab אבג\nx")
+
+	// Moving right from "b" crosses the space into the right-to-left run at
+	// its visually leftmost character, which is the last one logically.
+	expected := []int{1, 2, 5, 4, 3, 6}
+
+	for _, col := range expected {
+		ts.MoveRight()
+
+		if _, got := ts.GetCursorPosition(); got != col {
+			t.Errorf("MoveRight in bidi text failed. Expected cursorCol = %d, got = %d", col, got)
+		}
+	}
+
+	for i := len(expected) - 2; i >= 0; i-- {
+		ts.MoveLeft()
+
+		if _, got := ts.GetCursorPosition(); got != expected[i] {
+			t.Errorf("MoveLeft in bidi text failed. Expected cursorCol = %d, got = %d", expected[i], got)
+		}
+	}
+}
+
+func TestBidiSelection(t *testing.T) {
+	ts := NewTextSel().SetBidi(true).SetText("ab אבג")
+
+	// Moving visually from the space over "ג" to "ב" selects the logical
+	// range between the space and "ב", which does not include "ג".
+	ts.SetCursorPosition(0, 2).StartSelection().MoveRight().MoveRight()
+
+	if got := ts.GetSelectedText(); got != " אב" {
+		t.Errorf("Bidi selection failed. Expected ' אב', got: '%s'", got)
+	}
+}
+
+func TestHighlightBidi(t *testing.T) {
+	ts := NewTextSel()
+
+	app := tview.NewApplication()
+	app.SetRoot(ts, true)
+	app.SetFocus(ts)
+
+	ts.SetBidi(true).SetText("a [red]אב\nc").SetCursorPosition(0, 3)
+
+	expected := "[white:black:-:-]a[white:black:-:-] [red][red:black:-:-][black:white:-]ב[red:black:-:-][red:black:-:-]א[red:black:-:-] \nc"
+	if got := ts.TextView.GetText(false); got != expected {
+		t.Errorf("Bidi highlight failed.\n\nExpected: '%s'\n\n  Actual: '%s'\n\n", visualizeString(expected), visualizeString(got))
+	}
+}
+
+func TestBidiMirroring(t *testing.T) {
+	ts := NewTextSel().SetBidi(true).SetText("אב (ג) d")
+
+	// The parentheses are displayed right to left, so they are mirrored
+	ts.SetCursorPosition(1, 0)
+
+	if got := stripTags(ts.TextView.GetText(false)); got != "d (ג) בא" {
+		t.Errorf("Bidi mirroring failed. Expected 'd (ג) בא', got '%s'", got)
+	}
+}
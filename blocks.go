@@ -0,0 +1,254 @@
+package textsel
+
+// Range is a span of the displayed text. Positions are the same as those of
+// the cursor; the end is the position of the last character of the span.
+type Range struct {
+	StartRow int
+	StartCol int
+	EndRow   int
+	EndCol   int
+}
+
+// Returns true if the given position lies within the range.
+func (r Range) contains(row, col int) bool {
+	return !isBefore(row, col, r.StartRow, r.StartCol) && !isBefore(r.EndRow, r.EndCol, row, col)
+}
+
+// Block is a named span of the text defined by the application, e.g. one per
+// chat message or log record, with metadata of the application's choosing.
+// Unlike regions, blocks are not marked in the text itself.
+type Block struct {
+	ID       string
+	Range    Range
+	Metadata any
+}
+
+// AddBlock adds a block with the given ID, span and metadata, replacing any
+// block with the same ID. Blocks keep their positions when text is appended
+// with AppendText, ANSIWriter or SetText with text that starts with the
+// current text, and are removed when the text is replaced otherwise.
+//
+// A span that ends before it starts is reversed, and positions outside the
+// text are moved to its nearest character, so a block must be added after
+// its text.
+//
+// Example:
+//
+//	textSel.AddBlock("msg-1", textsel.Range{StartRow: 0, StartCol: 0, EndRow: 2, EndCol: 11}, message)
+func (ts *TextSel) AddBlock(id string, r Range, metadata any) *TextSel {
+	ts.RemoveBlock(id)
+
+	r = ts.clampRange(r)
+	block := Block{ID: id, Range: r, Metadata: metadata}
+
+	// Keep the blocks in the order of their start positions
+	i := len(ts.blocks)
+	for i > 0 && isBefore(r.StartRow, r.StartCol, ts.blocks[i-1].Range.StartRow, ts.blocks[i-1].Range.StartCol) {
+		i--
+	}
+
+	ts.blocks = append(ts.blocks[:i], append([]Block{block}, ts.blocks[i:]...)...)
+
+	return ts
+}
+
+// Returns the range with its start before its end and both positions moved
+// into the text.
+func (ts *TextSel) clampRange(r Range) Range {
+	if isBefore(r.EndRow, r.EndCol, r.StartRow, r.StartCol) {
+		r.StartRow, r.StartCol, r.EndRow, r.EndCol = r.EndRow, r.EndCol, r.StartRow, r.StartCol
+	}
+
+	lines := ts.getGraphemeLines()
+
+	clamp := func(row, col int) (int, int) {
+		switch {
+		case row < 0:
+			return 0, 0
+		case row >= len(lines):
+			row = len(lines) - 1
+			return row, max(len(lines[row])-1, 0)
+		}
+
+		return row, min(max(col, 0), max(len(lines[row])-1, 0))
+	}
+
+	r.StartRow, r.StartCol = clamp(r.StartRow, r.StartCol)
+	r.EndRow, r.EndCol = clamp(r.EndRow, r.EndCol)
+
+	return r
+}
+
+// RemoveBlock removes the block with the given ID, if there is one.
+//
+// Example:
+//
+//	textSel.RemoveBlock("msg-3")
+func (ts *TextSel) RemoveBlock(id string) *TextSel {
+	for i, block := range ts.blocks {
+		if block.ID == id {
+			ts.blocks = append(ts.blocks[:i], ts.blocks[i+1:]...)
+			break
+		}
+	}
+
+	return ts
+}
+
+// GetBlocks returns the blocks added with AddBlock, in the order of their
+// start positions.
+//
+// Example:
+//
+//	for _, block := range textSel.GetBlocks() {
+//		fmt.Println(block.ID, block.Range.StartRow)
+//	}
+func (ts *TextSel) GetBlocks() []Block {
+	return append([]Block{}, ts.blocks...)
+}
+
+// BlockAtCursor returns the block the cursor is in. If blocks are nested, the
+// innermost one, i.e. the one that starts last, is returned. Returns false if
+// the cursor is not in a block.
+//
+// Example:
+//
+//	if block, ok := textSel.BlockAtCursor(); ok {
+//		showDetails(block.Metadata.(*Message))
+//	}
+func (ts *TextSel) BlockAtCursor() (Block, bool) {
+	for i := len(ts.blocks) - 1; i >= 0; i-- {
+		if ts.blocks[i].Range.contains(ts.cursorRow, ts.cursorCol) {
+			return ts.blocks[i], true
+		}
+	}
+
+	return Block{}, false
+}
+
+// NextBlock moves the cursor to the start of the next block after the cursor.
+// If there is none, the cursor does not move.
+//
+// Example:
+//
+//	textSel.NextBlock()
+func (ts *TextSel) NextBlock() *TextSel {
+	for _, block := range ts.blocks {
+		if isBefore(ts.cursorRow, ts.cursorCol, block.Range.StartRow, block.Range.StartCol) {
+			return ts.SetCursorPosition(block.Range.StartRow, block.Range.StartCol)
+		}
+	}
+
+	return ts
+}
+
+// PrevBlock moves the cursor to the start of the previous block before the
+// cursor. If the cursor is inside a block, it moves to the start of that block
+// first. If there is none, the cursor does not move.
+//
+// Example:
+//
+//	textSel.PrevBlock()
+func (ts *TextSel) PrevBlock() *TextSel {
+	for i := len(ts.blocks) - 1; i >= 0; i-- {
+		r := ts.blocks[i].Range
+
+		if isBefore(r.StartRow, r.StartCol, ts.cursorRow, ts.cursorCol) {
+			return ts.SetCursorPosition(r.StartRow, r.StartCol)
+		}
+	}
+
+	return ts
+}
+
+// SelectBlock selects the block with the given ID and moves the cursor to its
+// end. Returns false if there is no such block.
+//
+// Example:
+//
+//	if textSel.SelectBlock("msg-3") {
+//		textSel.FinishSelection()
+//	}
+func (ts *TextSel) SelectBlock(id string) bool {
+	for _, block := range ts.blocks {
+		if block.ID == id {
+			ts.selectSpan(block.Range.StartRow, block.Range.StartCol, block.Range.EndRow, block.Range.EndCol)
+			return true
+		}
+	}
+
+	return false
+}
+
+// SelectBlockAtCursor selects the block the cursor is in (see BlockAtCursor),
+// and moves the cursor to its end. In ModePendingOperator, the operator
+// applies to the block. If the cursor is not in a block, nothing happens.
+//
+// Example:
+//
+//	textSel.SelectBlockAtCursor().FinishSelection()
+func (ts *TextSel) SelectBlockAtCursor() *TextSel {
+	if block, ok := ts.BlockAtCursor(); ok {
+		r := block.Range
+		ts.selectSpan(r.StartRow, r.StartCol, r.EndRow, r.EndCol)
+	}
+
+	return ts
+}
+
+// Returns the blocks that contain selected text.
+func (ts *TextSel) selectedBlocks() []Block {
+	blocks := []Block{}
+
+	if !ts.isSelecting() {
+		return blocks
+	}
+
+	lines := ts.getGraphemeLines()
+
+	for _, block := range ts.blocks {
+		r := block.Range
+
+		for row := max(r.StartRow, 0); row <= r.EndRow && row < len(lines); row++ {
+			first, last, ok := ts.selectedColumns(row, lines[row])
+			if !ok {
+				continue
+			}
+
+			// The columns of the block in this row
+			from, to := 0, len(lines[row])-1
+			if row == r.StartRow {
+				from = r.StartCol
+			}
+
+			if row == r.EndRow {
+				to = r.EndCol
+			}
+
+			if first <= to && last >= from {
+				blocks = append(blocks, block)
+				break
+			}
+		}
+	}
+
+	return blocks
+}
+
+// SetSelectBlocksFunc sets a callback that is called with the selected text
+// and the blocks that contain any of it, in the order of their start
+// positions, when a selection is finished. It is called after the callback
+// set with SetSelectFunc, which only takes the text so that existing callers
+// of SetSelectFunc keep working; set either or both.
+//
+// Example:
+//
+//	textSel.SetSelectBlocksFunc(func(text string, blocks []textsel.Block) {
+//		for _, block := range blocks {
+//			fmt.Println("quoted from", block.ID)
+//		}
+//	})
+func (ts *TextSel) SetSelectBlocksFunc(f func(text string, blocks []Block)) *TextSel {
+	ts.selectBlocksFunc = f
+	return ts
+}
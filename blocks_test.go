@@ -0,0 +1,206 @@
+package textsel
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newBlocksTextSel() *TextSel {
+	return NewTextSel().
+		SetText("alice: hi\nbob: hello\nthere").
+		AddBlock("msg-2", Range{StartRow: 1, StartCol: 5, EndRow: 2, EndCol: 4}, "bob").
+		AddBlock("msg-1", Range{StartRow: 0, StartCol: 7, EndRow: 0, EndCol: 8}, "alice")
+}
+
+func TestAddBlock(t *testing.T) {
+	ts := newBlocksTextSel()
+
+	ids := []string{}
+	for _, block := range ts.GetBlocks() {
+		ids = append(ids, block.ID)
+	}
+
+	if !reflect.DeepEqual(ids, []string{"msg-1", "msg-2"}) {
+		t.Errorf("AddBlock failed. Expected [msg-1 msg-2], got %v", ids)
+	}
+
+	ts.AddBlock("msg-1", Range{StartRow: 0, StartCol: 0, EndRow: 0, EndCol: 8}, "edited")
+
+	if blocks := ts.GetBlocks(); len(blocks) != 2 || blocks[0].Metadata != "edited" {
+		t.Errorf("AddBlock failed. Expected msg-1 to be replaced, got %v", blocks)
+	}
+
+	ts.RemoveBlock("msg-1")
+
+	if blocks := ts.GetBlocks(); len(blocks) != 1 || blocks[0].ID != "msg-2" {
+		t.Errorf("RemoveBlock failed. Expected only msg-2, got %v", blocks)
+	}
+}
+
+func TestAddBlockClampsRange(t *testing.T) {
+	tests := []struct {
+		r        Range
+		expected Range
+	}{
+		// Reversed spans are put in order
+		{Range{StartRow: 1, StartCol: 3, EndRow: 0, EndCol: 7}, Range{StartRow: 0, StartCol: 7, EndRow: 1, EndCol: 3}},
+		{Range{StartRow: 0, StartCol: 8, EndRow: 0, EndCol: 7}, Range{StartRow: 0, StartCol: 7, EndRow: 0, EndCol: 8}},
+		// Positions outside the text are moved into it
+		{Range{StartRow: -1, StartCol: 4, EndRow: 0, EndCol: 99}, Range{StartRow: 0, StartCol: 0, EndRow: 0, EndCol: 9}},
+		{Range{StartRow: 1, StartCol: -2, EndRow: 9, EndCol: 0}, Range{StartRow: 1, StartCol: 0, EndRow: 2, EndCol: 4}},
+		{Range{StartRow: 5, StartCol: 0, EndRow: 7, EndCol: 0}, Range{StartRow: 2, StartCol: 4, EndRow: 2, EndCol: 4}},
+	}
+
+	for _, test := range tests {
+		ts := NewTextSel().SetText("alice: hi\nbob: hello\nthere").AddBlock("b", test.r, nil)
+
+		if got := ts.GetBlocks()[0].Range; got != test.expected {
+			t.Errorf("AddBlock(%v) failed. Expected %v, got %v", test.r, test.expected, got)
+		}
+	}
+
+	// Blocks in empty text cover its only position
+	ts := NewTextSel().AddBlock("b", Range{StartRow: 2, StartCol: 2, EndRow: 3, EndCol: 3}, nil)
+
+	if got := ts.GetBlocks()[0].Range; got != (Range{}) {
+		t.Errorf("AddBlock in empty text failed. Expected %v, got %v", Range{}, got)
+	}
+}
+
+func TestBlocksSurviveAppends(t *testing.T) {
+	ts := newBlocksTextSel()
+
+	ts.AppendText("\n[red]carol:[-] hey")
+	ts.SetText(ts.GetText(false) + "\n")
+
+	if got := len(ts.GetBlocks()); got != 2 {
+		t.Errorf("Appending text failed. Expected 2 blocks, got %d", got)
+	}
+
+	ts.SetCursorPosition(3, 7)
+
+	if row, col := ts.GetCursorPosition(); row != 3 || col != 7 {
+		t.Errorf("AppendText failed. Expected the new text at (3, 7), got (%d, %d)", row, col)
+	}
+
+	ts.SetText("something else")
+
+	if got := len(ts.GetBlocks()); got != 0 {
+		t.Errorf("SetText failed. Expected no blocks, got %d", got)
+	}
+
+	// Blocks added before there was any text are stale
+	ts.SetText("").AddBlock("early", Range{}, nil).SetText("text")
+
+	if got := len(ts.GetBlocks()); got != 0 {
+		t.Errorf("SetText failed. Expected no blocks after empty text, got %d", got)
+	}
+}
+
+func TestAppendTextKeepsSource(t *testing.T) {
+	ts := NewTextSel().SetMarkdown("**bold**").SetReturnSource(true)
+	ts.AppendText(" [red]more")
+
+	if got := ts.GetText(true); got != "bold more" {
+		t.Errorf("AppendText failed. Expected %q, got %q", "bold more", got)
+	}
+
+	ts.StartSelection().SetCursorPosition(0, 3)
+
+	if got := ts.GetSelectedText(); got != "**bold**" {
+		t.Errorf("GetSelectedText within the Markdown failed. Expected %q, got %q", "**bold**", got)
+	}
+
+	ts.SetCursorPosition(0, 5)
+
+	if got := ts.GetSelectedText(); got != "bold m" {
+		t.Errorf("GetSelectedText across appended text failed. Expected %q, got %q", "bold m", got)
+	}
+
+	// ANSI text written after appended text is mapped again
+	ts.SetANSIText("\x1b[31mred\x1b[0m").AppendText(" plain ")
+	ts.ANSIWriter(nil).Write([]byte("\x1b[32mgreen"))
+	ts.SetCursorPosition(0, 10).StartSelection().MoveToEndOfLine()
+
+	if got := ts.GetSelectedTextAs(FormatANSI); got != "\x1b[0m\x1b[32mgreen\x1b[0m" {
+		t.Errorf("GetSelectedTextAs(FormatANSI) after appended text failed. Expected %q, got %q", "\x1b[0m\x1b[32mgreen\x1b[0m", got)
+	}
+}
+
+func TestBlockNavigation(t *testing.T) {
+	ts := newBlocksTextSel()
+
+	if block, ok := ts.BlockAtCursor(); ok {
+		t.Errorf("BlockAtCursor failed. Expected no block, got %v", block)
+	}
+
+	for _, expected := range [][2]int{{0, 7}, {1, 5}, {1, 5}} {
+		ts.NextBlock()
+
+		if row, col := ts.GetCursorPosition(); row != expected[0] || col != expected[1] {
+			t.Errorf("NextBlock failed. Expected (%d, %d), got (%d, %d)", expected[0], expected[1], row, col)
+		}
+	}
+
+	if block, ok := ts.BlockAtCursor(); !ok || block.Metadata != "bob" {
+		t.Errorf("BlockAtCursor failed. Expected msg-2, got %v, %v", block, ok)
+	}
+
+	ts.SetCursorPosition(2, 2)
+
+	for _, expected := range [][2]int{{1, 5}, {0, 7}, {0, 7}} {
+		ts.PrevBlock()
+
+		if row, col := ts.GetCursorPosition(); row != expected[0] || col != expected[1] {
+			t.Errorf("PrevBlock failed. Expected (%d, %d), got (%d, %d)", expected[0], expected[1], row, col)
+		}
+	}
+}
+
+func TestSelectBlock(t *testing.T) {
+	var selected string
+	var blocks []Block
+
+	ts := newBlocksTextSel().SetKeymap(VimKeymap()).
+		SetSelectBlocksFunc(func(text string, b []Block) {
+			selected, blocks = text, b
+		})
+
+	if ts.SelectBlock("missing") {
+		t.Errorf("SelectBlock failed. Expected false for an unknown block")
+	}
+
+	if !ts.SelectBlock("msg-2") {
+		t.Errorf("SelectBlock failed. Expected true for block 'msg-2'")
+	}
+
+	ts.FinishSelection()
+
+	if selected != "hello\nthere" || len(blocks) != 1 || blocks[0].ID != "msg-2" {
+		t.Errorf("SelectBlock failed. Expected 'hello\\nthere' in msg-2, got '%s' in %v", selected, blocks)
+	}
+
+	// A selection across messages reports all of them
+	ts.SetCursorPosition(0, 8).StartSelection().SetCursorPosition(1, 6).FinishSelection()
+
+	if selected != "i\nbob: he" || len(blocks) != 2 {
+		t.Errorf("FinishSelection failed. Expected 'i\\nbob: he' in 2 blocks, got '%s' in %v", selected, blocks)
+	}
+
+	// The speaker's name is not part of a block
+	ts.SetCursorPosition(1, 0).StartSelection().SetCursorPosition(1, 3).FinishSelection()
+
+	if len(blocks) != 0 {
+		t.Errorf("FinishSelection failed. Expected no blocks, got %v", blocks)
+	}
+
+	ts.SetCursorPosition(2, 1)
+
+	for _, r := range "yib" {
+		ts.handleKeyEvents(runeKey(r))
+	}
+
+	if selected != "hello\nthere" || len(blocks) != 1 {
+		t.Errorf("Yanking the block failed. Expected 'hello\\nthere', got '%s' in %v", selected, blocks)
+	}
+}
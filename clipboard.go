@@ -0,0 +1,211 @@
+package textsel
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Clipboard is a destination for selected text. When a clipboard is set with
+// SetClipboard, the selected text is copied to it each time a selection is
+// finished. Copy is called from a separate goroutine, so that a slow
+// clipboard does not block the event loop, but never for two selections at
+// once: they are copied one after another, in the order they were finished.
+type Clipboard interface {
+	Copy(text string) error
+}
+
+// SetClipboard sets the clipboard that selected text is copied to when a
+// selection is finished. Pass nil to disable copying.
+//
+// Example:
+//
+//	textSel.SetClipboard(textsel.NewCommandClipboard("wl-copy"))
+func (ts *TextSel) SetClipboard(clipboard Clipboard) *TextSel {
+	ts.clipboard = clipboard
+	return ts
+}
+
+// GetClipboard returns the clipboard set with SetClipboard, or nil if none has
+// been set.
+func (ts *TextSel) GetClipboard() Clipboard {
+	return ts.clipboard
+}
+
+// SetErrorFunc sets the callback function that will be called when an
+// operation triggered by the widget fails, such as copying to the clipboard.
+// Clipboard errors are reported from the goroutine that copies the text (see
+// Clipboard); use `Application.QueueUpdateDraw` to update the UI from it.
+//
+// Example:
+//
+//	textSel.SetErrorFunc(func(err error) {
+//		log.Println("textsel:", err)
+//	})
+func (ts *TextSel) SetErrorFunc(f func(error)) *TextSel {
+	ts.errorFunc = f
+	return ts
+}
+
+// Reports an error to the error callback, if one is set.
+func (ts *TextSel) reportError(err error) {
+	if err != nil && ts.errorFunc != nil {
+		ts.errorFunc(err)
+	}
+}
+
+// Copies text to the clipboard in the background, once the previous copy has
+// finished.
+func (ts *TextSel) copyToClipboard(text string) {
+	clipboard := ts.clipboard
+	previous := ts.lastCopy
+	done := make(chan struct{})
+	ts.lastCopy = done
+
+	go func() {
+		defer close(done)
+
+		if previous != nil {
+			<-previous
+		}
+
+		ts.reportError(clipboard.Copy(text))
+	}()
+}
+
+// OSC52Clipboard copies text to the terminal's clipboard by writing an OSC 52
+// escape sequence to the screen's tty. Because the sequence travels with the
+// rest of the terminal output, this works over SSH and inside tmux (with
+// tmux's `set-clipboard` option enabled, or by setting Tmux to true).
+type OSC52Clipboard struct {
+	// App is the application that draws to the screen. The escape sequence is
+	// written while it is not drawing, so that it does not end up in the
+	// middle of the screen's output. If App is nil, no care is taken.
+	App *tview.Application
+
+	// Screen is the screen whose tty receives the escape sequence.
+	Screen tcell.Screen
+
+	// Tmux wraps the escape sequence in a tmux DCS passthrough sequence so
+	// that it reaches the outer terminal even when tmux's `set-clipboard`
+	// option is off (tmux's `allow-passthrough` must be on).
+	Tmux bool
+}
+
+// NewOSC52Clipboard creates a new OSC52Clipboard that writes to the tty of the
+// given screen in between the draws of the given application. Use the screen
+// that is passed to the application with `SetScreen`.
+//
+// Example:
+//
+//	screen, _ := tcell.NewScreen()
+//	app.SetScreen(screen)
+//	textSel.SetClipboard(textsel.NewOSC52Clipboard(app, screen))
+func NewOSC52Clipboard(app *tview.Application, screen tcell.Screen) *OSC52Clipboard {
+	return &OSC52Clipboard{App: app, Screen: screen}
+}
+
+// Copy writes the OSC 52 sequence for text to the screen's tty.
+func (c *OSC52Clipboard) Copy(text string) error {
+	if c.Screen == nil {
+		return errors.New("osc52 clipboard: no screen")
+	}
+
+	tty, ok := c.Screen.Tty()
+	if !ok {
+		return errors.New("osc52 clipboard: screen is not a terminal")
+	}
+
+	// The application draws while holding its lock
+	if c.App != nil {
+		c.App.Lock()
+		defer c.App.Unlock()
+	}
+
+	_, err := tty.Write([]byte(c.sequence(text)))
+	return err
+}
+
+// Returns the escape sequence that places text in the system clipboard.
+func (c *OSC52Clipboard) sequence(text string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+
+	if c.Tmux {
+		// Inside a passthrough sequence, every ESC must be doubled.
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+
+	return seq
+}
+
+// CommandClipboard copies text by running an external command with the text on
+// its standard input, e.g. `xclip -selection clipboard`, `wl-copy` or
+// `pbcopy`.
+type CommandClipboard struct {
+	Name string
+	Args []string
+}
+
+// NewCommandClipboard creates a new CommandClipboard that runs the named
+// executable with the given arguments.
+//
+// Example:
+//
+//	clipboard := textsel.NewCommandClipboard("xclip", "-selection", "clipboard")
+func NewCommandClipboard(name string, args ...string) *CommandClipboard {
+	return &CommandClipboard{Name: name, Args: args}
+}
+
+// Copy runs the command, writing text to its standard input.
+func (c *CommandClipboard) Copy(text string) error {
+	stderr := bytes.Buffer{}
+
+	cmd := exec.Command(c.Name, c.Args...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", c.Name, err, msg)
+		}
+
+		return fmt.Errorf("%s: %w", c.Name, err)
+	}
+
+	return nil
+}
+
+// MemoryClipboard keeps copied text in memory. It is mostly useful in tests.
+type MemoryClipboard struct {
+	mu   sync.Mutex
+	text string
+}
+
+// NewMemoryClipboard creates a new, empty MemoryClipboard.
+func NewMemoryClipboard() *MemoryClipboard {
+	return &MemoryClipboard{}
+}
+
+// Copy stores text, replacing anything copied previously.
+func (c *MemoryClipboard) Copy(text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.text = text
+	return nil
+}
+
+// Text returns the most recently copied text.
+func (c *MemoryClipboard) Text() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.text
+}
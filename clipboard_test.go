@@ -0,0 +1,191 @@
+package textsel
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// A clipboard that sends the copied text to a channel, after waiting for a
+// value from release if set, and fails with err if set.
+type fakeClipboard struct {
+	copied  chan string
+	release chan struct{}
+	err     error
+}
+
+func newFakeClipboard() *fakeClipboard {
+	return &fakeClipboard{copied: make(chan string)}
+}
+
+func (c *fakeClipboard) Copy(text string) error {
+	if c.release != nil {
+		<-c.release
+	}
+
+	c.copied <- text
+	return c.err
+}
+
+func TestMemoryClipboard(t *testing.T) {
+	clipboard := NewMemoryClipboard()
+	clipboard.Copy("Hel")
+
+	if got := clipboard.Text(); got != "Hel" {
+		t.Errorf("MemoryClipboard failed. Expected 'Hel', got: '%s'", got)
+	}
+}
+
+func TestFinishSelectionCopies(t *testing.T) {
+	clipboard := newFakeClipboard()
+	ts := NewTextSel().SetText("Hello, World!").SetClipboard(clipboard)
+
+	ts.StartSelection().MoveRight().MoveRight().FinishSelection()
+
+	if got := <-clipboard.copied; got != "Hel" {
+		t.Errorf("FinishSelection failed to copy to clipboard. Expected 'Hel', got: '%s'", got)
+	}
+
+	// Finishing without an active selection must not clobber the clipboard,
+	// so the next text copied is that of the next selection
+	ts.FinishSelection()
+	ts.StartSelection().FinishSelection()
+
+	if got := <-clipboard.copied; got != "l" {
+		t.Errorf("FinishSelection without selection copied to the clipboard. Expected 'l' next, got: '%s'", got)
+	}
+}
+
+func TestClipboardInBackground(t *testing.T) {
+	clipboard := newFakeClipboard()
+	clipboard.release = make(chan struct{})
+	ts := NewTextSel().SetText("Hello, World!").SetClipboard(clipboard)
+
+	// FinishSelection returns while the clipboard is still busy, and the
+	// selections are copied in order
+	ts.StartSelection().FinishSelection()
+	ts.MoveRight().StartSelection().FinishSelection()
+
+	for _, expected := range []string{"H", "e"} {
+		clipboard.release <- struct{}{}
+
+		if got := <-clipboard.copied; got != expected {
+			t.Errorf("Copying in the background failed. Expected '%s', got: '%s'", expected, got)
+		}
+	}
+}
+
+func TestCommandClipboard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clipboard")
+
+	if err := NewCommandClipboard("sh", "-c", "cat > "+path).Copy("Hello, World!"); err != nil {
+		t.Fatalf("CommandClipboard did not run the command: %v", err)
+	}
+
+	if got, _ := os.ReadFile(path); string(got) != "Hello, World!" {
+		t.Errorf("CommandClipboard failed. Expected 'Hello, World!', got: '%s'", got)
+	}
+
+	err := NewCommandClipboard("sh", "-c", "echo nope >&2; exit 1").Copy("Hello")
+	if err == nil || err.Error() != "sh: exit status 1: nope" {
+		t.Errorf("Unexpected clipboard error. Expected 'sh: exit status 1: nope', got: '%v'", err)
+	}
+}
+
+func TestClipboardError(t *testing.T) {
+	reported := make(chan error, 1)
+	clipboard := newFakeClipboard()
+	clipboard.err = errors.New("nope")
+
+	ts := NewTextSel().
+		SetText("Hello, World!").
+		SetClipboard(clipboard).
+		SetErrorFunc(func(err error) { reported <- err })
+
+	ts.StartSelection().FinishSelection()
+	<-clipboard.copied
+
+	if err := <-reported; err != clipboard.err {
+		t.Errorf("Unexpected clipboard error. Expected 'nope', got: '%v'", err)
+	}
+}
+
+func TestOSC52Sequence(t *testing.T) {
+	c := NewOSC52Clipboard(nil, nil)
+
+	expected := "\x1b]52;c;SGVsbG8=\a"
+	if got := c.sequence("Hello"); got != expected {
+		t.Errorf("OSC 52 sequence failed. Expected %q, got %q", expected, got)
+	}
+
+	c.Tmux = true
+
+	expected = "\x1bPtmux;\x1b\x1b]52;c;SGVsbG8=\a\x1b\\"
+	if got := c.sequence("Hello"); got != expected {
+		t.Errorf("OSC 52 tmux sequence failed. Expected %q, got %q", expected, got)
+	}
+}
+
+func TestOSC52ClipboardWithoutTty(t *testing.T) {
+	if err := NewOSC52Clipboard(nil, nil).Copy("Hello"); err == nil {
+		t.Error("OSC52Clipboard without a screen should fail")
+	}
+
+	screen := tcell.NewSimulationScreen("")
+	if err := NewOSC52Clipboard(tview.NewApplication(), screen).Copy("Hello"); err == nil {
+		t.Error("OSC52Clipboard without a tty should fail")
+	}
+}
+
+// A screen whose tty sends what is written to it to a channel.
+type ttyScreen struct {
+	tcell.SimulationScreen
+	tty *chanTty
+}
+
+func (s ttyScreen) Tty() (tcell.Tty, bool) {
+	return s.tty, true
+}
+
+type chanTty struct {
+	tcell.Tty
+	written chan string
+}
+
+func (t *chanTty) Write(p []byte) (int, error) {
+	t.written <- string(p)
+	return len(p), nil
+}
+
+func TestOSC52ClipboardWaitsForDraw(t *testing.T) {
+	app := tview.NewApplication()
+	tty := &chanTty{written: make(chan string, 1)}
+	screen := ttyScreen{SimulationScreen: tcell.NewSimulationScreen(""), tty: tty}
+	clipboard := NewOSC52Clipboard(app, screen)
+
+	// The application holds its lock while drawing
+	app.Lock()
+
+	done := make(chan error)
+	go func() { done <- clipboard.Copy("Hello") }()
+
+	select {
+	case <-tty.written:
+		t.Error("OSC52Clipboard wrote to the tty while the application was drawing")
+	default:
+	}
+
+	app.Unlock()
+
+	if err := <-done; err != nil {
+		t.Fatalf("OSC52Clipboard failed: %v", err)
+	}
+
+	if got := <-tty.written; got != "\x1b]52;c;SGVsbG8=\a" {
+		t.Errorf("OSC52Clipboard wrote the wrong sequence. Expected %q, got %q", "\x1b]52;c;SGVsbG8=\a", got)
+	}
+}
@@ -0,0 +1,215 @@
+package textsel
+
+import "strings"
+
+// CodeBlock is a fenced code block in the text, i.e. lines between an
+// opening fence of three or more backticks or tildes, optionally followed by
+// a language tag, and a closing fence of the same kind, as in Markdown. Rows
+// are those of the fences; a block without a closing fence ends at the last
+// row of the text.
+type CodeBlock struct {
+	Language string
+	StartRow int
+	EndRow   int
+
+	closed bool
+}
+
+// Returns the rows of the contents of the block, i.e. without the fences.
+// The last row is before the first if the block is empty.
+func (b CodeBlock) contentRows() (int, int) {
+	if b.closed {
+		return b.StartRow + 1, b.EndRow - 1
+	}
+
+	return b.StartRow + 1, b.EndRow
+}
+
+// GetCodeBlocks returns the fenced code blocks in the text displayed, in the
+// order in which they appear. Fences are found in the text without format
+// codes, so this works for plain text as well as for text set with
+// SetMarkdown.
+//
+// Example:
+//
+//	for _, block := range textSel.GetCodeBlocks() {
+//		fmt.Println(block.Language, block.StartRow, block.EndRow)
+//	}
+func (ts *TextSel) GetCodeBlocks() []CodeBlock {
+	blocks := []CodeBlock{}
+	lines := ts.getLines()
+	current := CodeBlock{}
+	fence := ""
+
+	for row, line := range lines {
+		line = strings.TrimRight(line, "\r\n")
+
+		match := markdownFence.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		info := strings.TrimSpace(line[len(match[0]):])
+
+		if fence == "" {
+			// The language tag of a backtick fence can't contain backticks
+			if match[1][0] == '`' && strings.Contains(info, "`") {
+				continue
+			}
+
+			fence = match[1]
+			current = CodeBlock{StartRow: row}
+
+			if fields := strings.Fields(info); len(fields) > 0 {
+				current.Language = fields[0]
+			}
+		} else if match[1][0] == fence[0] && len(match[1]) >= len(fence) && info == "" {
+			current.EndRow = row
+			current.closed = true
+			blocks = append(blocks, current)
+			fence = ""
+		}
+	}
+
+	if fence != "" {
+		current.EndRow = len(lines) - 1
+		blocks = append(blocks, current)
+	}
+
+	return blocks
+}
+
+// CodeBlockAtCursor returns the code block the cursor is in, including its
+// fences. Returns false if the cursor is not in a code block.
+//
+// Example:
+//
+//	if block, ok := textSel.CodeBlockAtCursor(); ok {
+//		fmt.Println("language:", block.Language)
+//	}
+func (ts *TextSel) CodeBlockAtCursor() (CodeBlock, bool) {
+	for _, block := range ts.GetCodeBlocks() {
+		if ts.cursorRow >= block.StartRow && ts.cursorRow <= block.EndRow {
+			return block, true
+		}
+	}
+
+	return CodeBlock{}, false
+}
+
+// NextCodeBlock moves the cursor to the start of the first line of the next
+// code block after the cursor. If there is none, the cursor does not move.
+//
+// Example:
+//
+//	textSel.NextCodeBlock()
+func (ts *TextSel) NextCodeBlock() *TextSel {
+	for _, block := range ts.GetCodeBlocks() {
+		if block.StartRow > ts.cursorRow {
+			return ts.SetCursorPosition(block.StartRow, 0)
+		}
+	}
+
+	return ts
+}
+
+// PrevCodeBlock moves the cursor to the start of the first line of the
+// previous code block before the cursor. If the cursor is inside a block, it
+// moves to the start of that block first. If there is none, the cursor does
+// not move.
+//
+// Example:
+//
+//	textSel.PrevCodeBlock()
+func (ts *TextSel) PrevCodeBlock() *TextSel {
+	blocks := ts.GetCodeBlocks()
+
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if isBefore(blocks[i].StartRow, 0, ts.cursorRow, ts.cursorCol) {
+			return ts.SetCursorPosition(blocks[i].StartRow, 0)
+		}
+	}
+
+	return ts
+}
+
+// Returns true if a line, split into grapheme clusters, has no characters
+// other than its line ending.
+func isBlankLine(line []string) bool {
+	return len(line) == 0 || (len(line) == 1 && isLineEnding(line[0]))
+}
+
+// Returns the code block the cursor is in, or else the next one after it.
+func (ts *TextSel) codeBlockAtOrAfterCursor() (CodeBlock, bool) {
+	for _, block := range ts.GetCodeBlocks() {
+		if block.EndRow >= ts.cursorRow {
+			return block, true
+		}
+	}
+
+	return CodeBlock{}, false
+}
+
+// SelectCodeBlock selects the contents of the code block the cursor is in,
+// or else of the next one after it, without the fences and the language tag,
+// and moves the cursor to its end. Empty lines at the end and the line ending
+// of the last line are not selected. In ModePendingOperator, the operator
+// applies to the contents. If there is no such block or it is empty, nothing
+// happens. SelectCodeBlockWithFences selects the fences and language tag, too.
+//
+// Example:
+//
+//	textSel.SelectCodeBlock().FinishSelection()
+func (ts *TextSel) SelectCodeBlock() *TextSel {
+	block, ok := ts.codeBlockAtOrAfterCursor()
+	if !ok {
+		return ts
+	}
+
+	lines := ts.getGraphemeLines()
+	first, last := block.contentRows()
+
+	// Leave out empty lines at the end
+	for last >= first && isBlankLine(lines[last]) {
+		last--
+	}
+
+	if last < first {
+		return ts
+	}
+
+	col := len(lines[last]) - 1
+	if isLineEnding(lines[last][col]) {
+		col--
+	}
+
+	ts.selectSpan(first, 0, last, col)
+
+	return ts
+}
+
+// SelectCodeBlockWithFences selects the code block the cursor is in, or else
+// the next one after it, including its fences and language tag, and moves the
+// cursor to its end. In ModePendingOperator, the operator applies to the
+// block. If there is no such block, nothing happens.
+//
+// Example:
+//
+//	textSel.SelectCodeBlockWithFences().FinishSelection()
+func (ts *TextSel) SelectCodeBlockWithFences() *TextSel {
+	block, ok := ts.codeBlockAtOrAfterCursor()
+	if !ok {
+		return ts
+	}
+
+	lines := ts.getGraphemeLines()
+	col := max(len(lines[block.EndRow])-1, 0)
+
+	if col > 0 && isLineEnding(lines[block.EndRow][col]) {
+		col--
+	}
+
+	ts.selectSpan(block.StartRow, 0, block.EndRow, col)
+
+	return ts
+}
@@ -0,0 +1,109 @@
+package textsel
+
+import (
+	"reflect"
+	"testing"
+)
+
+const codeBlockText = "Run this:\n```sh\ngo test ./...\n\n```\nor\n~~~\n```\nnested\n~~~~\nthen\n````go\nfmt.Println()"
+
+func TestGetCodeBlocks(t *testing.T) {
+	ts := NewTextSel().SetText(codeBlockText)
+
+	expected := []CodeBlock{
+		{Language: "sh", StartRow: 1, EndRow: 4, closed: true},
+		{Language: "", StartRow: 6, EndRow: 9, closed: true},
+		{Language: "go", StartRow: 11, EndRow: 12},
+	}
+
+	if got := ts.GetCodeBlocks(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("GetCodeBlocks failed. Expected %v, got %v", expected, got)
+	}
+
+	ts.SetCursorPosition(3, 0)
+
+	if block, ok := ts.CodeBlockAtCursor(); !ok || block.Language != "sh" {
+		t.Errorf("CodeBlockAtCursor failed. Expected the sh block, got %v, %v", block, ok)
+	}
+
+	ts.SetCursorPosition(5, 0)
+
+	if block, ok := ts.CodeBlockAtCursor(); ok {
+		t.Errorf("CodeBlockAtCursor failed. Expected no block, got %v", block)
+	}
+}
+
+func TestNextAndPrevCodeBlock(t *testing.T) {
+	ts := NewTextSel().SetText(codeBlockText)
+
+	for _, expected := range []int{1, 6, 11, 11} {
+		ts.NextCodeBlock()
+
+		if row, col := ts.GetCursorPosition(); row != expected || col != 0 {
+			t.Errorf("NextCodeBlock failed. Expected (%d, 0), got (%d, %d)", expected, row, col)
+		}
+	}
+
+	ts.SetCursorPosition(8, 2)
+
+	for _, expected := range []int{6, 1, 1} {
+		ts.PrevCodeBlock()
+
+		if row, col := ts.GetCursorPosition(); row != expected || col != 0 {
+			t.Errorf("PrevCodeBlock failed. Expected (%d, 0), got (%d, %d)", expected, row, col)
+		}
+	}
+}
+
+func TestSelectCodeBlock(t *testing.T) {
+	var selected string
+
+	ts := NewTextSel().SetKeymap(VimKeymap()).
+		SetText(codeBlockText).
+		SetSelectFunc(func(text string) { selected = text })
+
+	tests := []struct {
+		row      int
+		contents string
+		all      string
+	}{
+		{0, "go test ./...", "```sh\ngo test ./...\n\n```"},
+		{7, "```\nnested", "~~~\n```\nnested\n~~~~"},
+		{10, "fmt.Println()", "````go\nfmt.Println()"},
+	}
+
+	for _, test := range tests {
+		ts.ResetSelection()
+		ts.SetCursorPosition(test.row, 0).SelectCodeBlock()
+
+		if got := ts.GetSelectedText(); got != test.contents {
+			t.Errorf("SelectCodeBlock from row %d failed. Expected %q, got %q", test.row, test.contents, got)
+		}
+
+		ts.ResetSelection()
+		ts.SetCursorPosition(test.row, 0).SelectCodeBlockWithFences()
+
+		if got := ts.GetSelectedText(); got != test.all {
+			t.Errorf("SelectCodeBlockWithFences from row %d failed. Expected %q, got %q", test.row, test.all, got)
+		}
+	}
+
+	ts.ResetSelection()
+	ts.SetCursorPosition(0, 0)
+
+	for _, r := range "]c]cyic" {
+		ts.handleKeyEvents(runeKey(r))
+	}
+
+	if selected != "```\nnested" {
+		t.Errorf("Yanking the code block failed. Expected %q, got %q", "```\nnested", selected)
+	}
+
+	// Text set with SetMarkdown keeps its fences
+	ts.SetMarkdown("Try:\n\n```go\nx := `[red]`\n```\n").SetCursorPosition(0, 0)
+	ts.SelectCodeBlock()
+
+	if got := ts.GetSelectedText(); got != "x := `[red]`" {
+		t.Errorf("SelectCodeBlock in Markdown failed. Expected %q, got %q", "x := `[red]`", got)
+	}
+}
@@ -0,0 +1,92 @@
+package textsel
+
+import "github.com/rivo/uniseg"
+
+// SetTabWidth sets the distance between tab stops. Tabs are expanded to
+// spaces up to the next tab stop when the text is displayed, and vertical
+// movement and block selections take the expanded width into account. The
+// default is `tview.TabSize`.
+//
+// Example:
+//
+//	textSel.SetTabWidth(8)
+func (ts *TextSel) SetTabWidth(width int) *TextSel {
+	ts.tabWidth = max(width, 1)
+	ts.highlightCursor()
+	return ts
+}
+
+// GetTabWidth returns the distance between tab stops.
+func (ts *TextSel) GetTabWidth() int {
+	return ts.tabWidth
+}
+
+// Returns the number of screen cells a grapheme cluster occupies. East Asian
+// wide characters and most emoji occupy two cells. A line ending occupies one
+// cell, because the cursor is drawn on it as a space. Tabs depend on their
+// position; see cellWidth.
+func clusterWidth(cluster string) int {
+	if isLineEnding(cluster) {
+		return 1
+	}
+
+	return uniseg.StringWidth(cluster)
+}
+
+// Returns the number of screen cells a grapheme cluster occupies when it is
+// displayed at the given cell. A tab extends to the next tab stop.
+func (ts *TextSel) cellWidth(cluster string, cell int) int {
+	if cluster == "\t" {
+		return ts.tabWidth - cell%ts.tabWidth
+	}
+
+	return clusterWidth(cluster)
+}
+
+// Returns the screen cell (relative to the start of the line) at which the
+// given column of a line starts. Columns beyond the end of the line are
+// assumed to be one cell wide.
+func (ts *TextSel) displayColumn(line []string, col int) int {
+	cell := 0
+
+	for c := 0; c < col; c++ {
+		if c < len(line) {
+			cell += ts.cellWidth(line[c], cell)
+		} else {
+			cell++
+		}
+	}
+
+	return cell
+}
+
+// Returns the column of a line that covers the given screen cell. Cells
+// beyond the end of the line map to columns beyond its last one.
+func (ts *TextSel) columnAtDisplay(line []string, cell int) int {
+	start := 0
+
+	for col, cluster := range line {
+		width := ts.cellWidth(cluster, start)
+
+		if cell < start+width {
+			return col
+		}
+
+		start += width
+	}
+
+	return len(line) + cell - start
+}
+
+// Returns the first and last screen cell covered by the given column of a
+// line.
+func (ts *TextSel) cellSpan(line []string, col int) (int, int) {
+	left := ts.displayColumn(line, col)
+	width := 1
+
+	if col < len(line) {
+		width = max(ts.cellWidth(line[col], left), 1)
+	}
+
+	return left, left + width - 1
+}
@@ -0,0 +1,82 @@
+package textsel
+
+import (
+	"testing"
+
+	"github.com/rivo/tview"
+)
+
+func TestDisplayColumns(t *testing.T) {
+	ts := NewTextSel().SetTabWidth(4)
+	line := graphemes("a\tb世\tc\n")
+
+	// a=0, tab=1-3, b=4, 世=5-6, tab=7, c=8, newline=9
+	expected := []int{0, 1, 4, 5, 7, 8, 9}
+
+	for col, cell := range expected {
+		if got := ts.displayColumn(line, col); got != cell {
+			t.Errorf("displayColumn(%d) failed. Expected %d, got %d", col, cell, got)
+		}
+
+		if got := ts.columnAtDisplay(line, cell); got != col {
+			t.Errorf("columnAtDisplay(%d) failed. Expected %d, got %d", cell, col, got)
+		}
+	}
+
+	if got := ts.columnAtDisplay(line, 2); got != 1 {
+		t.Errorf("columnAtDisplay() inside a tab failed. Expected 1, got %d", got)
+	}
+
+	ts.SetTabWidth(8)
+
+	if got := ts.displayColumn(line, 2); got != 8 {
+		t.Errorf("displayColumn() with tab width 8 failed. Expected 8, got %d", got)
+	}
+
+	if ts.SetTabWidth(0).GetTabWidth() != 1 {
+		t.Errorf("SetTabWidth() failed to enforce a minimum width of 1. Got %d", ts.GetTabWidth())
+	}
+}
+
+func TestMoveVerticallyThroughTabs(t *testing.T) {
+	ts := NewTextSel().SetText("\tfoo\n        bar\n\t\tbaz").SetTabWidth(4)
+
+	// "f" is at cell 4, which is the fifth space of the second line
+	ts.SetCursorPosition(0, 1).MoveDown()
+
+	if row, col := ts.GetCursorPosition(); row != 1 || col != 4 {
+		t.Errorf("MoveDown through a tab failed. Expected (1, 4), got (%d, %d)", row, col)
+	}
+
+	// ...and the second tab of the third line
+	ts.MoveDown()
+
+	if row, col := ts.GetCursorPosition(); row != 2 || col != 1 {
+		t.Errorf("MoveDown onto a tab failed. Expected (2, 1), got (%d, %d)", row, col)
+	}
+}
+
+func TestHighlightTabs(t *testing.T) {
+	ts := NewTextSel()
+
+	app := tview.NewApplication()
+	app.SetRoot(ts, true)
+	app.SetFocus(ts)
+
+	ts.SetText("a\tb").SetTabWidth(4).SetCursorPosition(0, 1)
+
+	expected := "a[black:white:-]   [white:black:-:-]b"
+	if got := ts.TextView.GetText(false); got != expected {
+		t.Errorf("Tab highlight failed.\n\nExpected: '%s'\n\n  Actual: '%s'\n\n", visualizeString(expected), visualizeString(got))
+	}
+}
+
+func TestBlockSelectionTabs(t *testing.T) {
+	ts := NewTextSel().SetText("\tabc\n    def\n  \tghi").SetTabWidth(4)
+
+	ts.SetCursorPosition(0, 1).StartBlockSelection().MoveDown().MoveDown().MoveRight()
+
+	if got := ts.GetSelectedText(); got != "ab\nde\ngh" {
+		t.Errorf("Block selection with tabs failed. Expected 'ab\\nde\\ngh', got: '%s'", got)
+	}
+}
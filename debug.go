@@ -31,7 +31,7 @@ func (ts *TextSel) debugCursor() *TextSel {
 
 // Debug function to log the selection range.
 func (ts *TextSel) debugSelection() *TextSel {
-	if ts.isSelecting {
+	if ts.isSelecting() {
 		startRow, startCol, endRow, endCol := ts.GetSelectionRange()
 		ts.debug("Selection range: (%d, %d) - (%d, %d)", startRow, startCol, endRow, endCol)
 	} else {
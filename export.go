@@ -0,0 +1,511 @@
+package textsel
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// SelectionFormat is a format in which the selected text can be returned by
+// GetSelectedTextAs.
+type SelectionFormat int
+
+const (
+	// Plain text without any formatting, as returned by GetSelectedText
+	FormatPlain SelectionFormat = iota
+
+	// Text with tview style and region tags, as returned by
+	// GetSelectedTextFormatted
+	FormatTview
+
+	// Text with ANSI SGR escape sequences for colors and attributes, and OSC 8
+	// sequences for hyperlinks. If the text was set with SetANSIText or
+	// written to ANSIWriter, the original escape sequences are returned.
+	FormatANSI
+
+	// An HTML <pre> element with inline styles
+	FormatHTML
+
+	// Markdown, with bold, italic and strikethrough text and links. If the
+	// text was set with SetMarkdown, the Markdown source is returned.
+	FormatMarkdown
+)
+
+// String returns the name of the format.
+func (f SelectionFormat) String() string {
+	switch f {
+	case FormatPlain:
+		return "plain"
+	case FormatTview:
+		return "tview"
+	case FormatANSI:
+		return "ansi"
+	case FormatHTML:
+		return "html"
+	case FormatMarkdown:
+		return "markdown"
+	default:
+		return fmt.Sprintf("SelectionFormat(%d)", int(f))
+	}
+}
+
+// A run of selected text with the same style and region.
+type styledText struct {
+	style  formatCode
+	region string
+	text   string
+}
+
+// GetSelectedTextAs returns the currently selected text in the given format,
+// keeping the colors and attributes given by the format codes in the text as
+// far as the format supports them. Text in the default colors is written
+// without colors. If no text is selected, an empty string is returned.
+//
+// Example:
+//
+//	fmt.Fprint(logFile, textSel.GetSelectedTextAs(textsel.FormatANSI))
+func (ts *TextSel) GetSelectedTextAs(format SelectionFormat) string {
+	switch format {
+	case FormatTview:
+		return ts.GetSelectedTextFormatted()
+	case FormatANSI:
+		if _, ok := ts.source.(*ansiTranslator); ok {
+			if text, ok := ts.selectedSource(); ok {
+				return text
+			}
+		}
+
+		return runsToANSI(ts.selectedRuns())
+	case FormatHTML:
+		return runsToHTML(ts.selectedRuns())
+	case FormatMarkdown:
+		if _, ok := ts.source.(*markdownRenderer); ok {
+			if text, ok := ts.selectedSource(); ok {
+				return text
+			}
+		}
+
+		return runsToMarkdown(ts.selectedRuns())
+	default:
+		return ts.GetSelectedText()
+	}
+}
+
+// Returns the selected text split into runs with the same style and region.
+// In ModeVisualBlock, the parts of the rows are separated by newlines.
+func (ts *TextSel) selectedRuns() []styledText {
+	runs := []styledText{}
+
+	if !ts.isSelecting() {
+		return runs
+	}
+
+	lines := ts.getGraphemeLines()
+	startRow, _, endRow, _ := ts.GetSelectionRange()
+
+	formatCode := newFormatCode()
+	region := ""
+
+	selRow := -1
+	first, last, rowSelected := 0, 0, false
+	row, col := 0, 0
+
+	write := func(text string) {
+		if n := len(runs) - 1; n >= 0 && runs[n].style == formatCode && runs[n].region == region {
+			runs[n].text += text
+		} else {
+			runs = append(runs, styledText{style: formatCode, region: region, text: text})
+		}
+	}
+
+	for _, token := range splitTags(ts.text) {
+		if row > endRow || row >= len(lines) {
+			break
+		}
+
+		if token.isRegionTag() {
+			region = token.raw[2 : len(token.raw)-2]
+			continue
+		}
+
+		if token.isTag() {
+			formatCode = formatCode.update(token.raw)
+			continue
+		}
+
+		if row != selRow {
+			selRow = row
+			first, last, rowSelected = ts.selectedColumns(row, lines[row])
+
+			if ts.selectionMode() == ModeVisualBlock && row > startRow {
+				write("\n")
+			}
+		}
+
+		if rowSelected && col >= first && col <= last {
+			char := token.char
+			if isLineEnding(char) && !ts.preserveLineEndings {
+				char = "\n"
+			}
+
+			write(char)
+		}
+
+		if isLineEnding(token.char) {
+			row++
+			col = 0
+		} else {
+			col++
+		}
+	}
+
+	return runs
+}
+
+// Returns the runs as text with tview tags.
+func runsToTview(runs []styledText) string {
+	if len(runs) == 0 {
+		return ""
+	}
+
+	buf := strings.Builder{}
+	out := newTagWriter(&buf)
+	region := ""
+
+	for i, run := range runs {
+		if i == 0 || run.style != runs[i-1].style {
+			out.WriteTag(run.style.String())
+		}
+
+		if run.region != region {
+			out.WriteTag(`["` + run.region + `"]`)
+			region = run.region
+		}
+
+		out.WriteText(run.text)
+	}
+
+	if region != "" {
+		out.WriteTag(`[""]`)
+	}
+
+	out.WriteTag("[-:-:-:-]")
+
+	return buf.String()
+}
+
+// SGR parameters of the attributes.
+var ansiAttributes = []struct {
+	attr tcell.AttrMask
+	code string
+}{
+	{tcell.AttrBold, "1"},
+	{tcell.AttrDim, "2"},
+	{tcell.AttrItalic, "3"},
+	{tcell.AttrUnderline, "4"},
+	{tcell.AttrBlink, "5"},
+	{tcell.AttrReverse, "7"},
+	{tcell.AttrStrikeThrough, "9"},
+}
+
+// Returns the SGR parameters for a foreground or background color, or "" for
+// the default color.
+func ansiColor(c tcell.Color, background bool) string {
+	base := 30
+	if background {
+		base = 40
+	}
+
+	// Palette colors are numbered from ColorBlack
+	index := int(c - tcell.ColorBlack)
+
+	switch {
+	case !c.Valid():
+		return ""
+	case c.IsRGB():
+		r, g, b := c.RGB()
+		return fmt.Sprintf("%d;2;%d;%d;%d", base+8, r, g, b)
+	case index < 8:
+		return fmt.Sprint(base + index)
+	case index < 16:
+		return fmt.Sprint(base + 60 + index - 8)
+	default:
+		return fmt.Sprintf("%d;5;%d", base+8, index)
+	}
+}
+
+// Returns the runs as text with ANSI escape sequences.
+func runsToANSI(runs []styledText) string {
+	if len(runs) == 0 {
+		return ""
+	}
+
+	defaults := newFormatCode()
+	buf := strings.Builder{}
+	url := ""
+
+	for i, run := range runs {
+		if i == 0 || run.style != runs[i-1].style {
+			params := []string{"0"}
+
+			if run.style.fg != defaults.fg {
+				if color := ansiColor(run.style.fg, false); color != "" {
+					params = append(params, color)
+				}
+			}
+
+			if run.style.bg != defaults.bg {
+				if color := ansiColor(run.style.bg, true); color != "" {
+					params = append(params, color)
+				}
+			}
+
+			for _, a := range ansiAttributes {
+				if run.style.attrs&a.attr != 0 {
+					params = append(params, a.code)
+				}
+			}
+
+			buf.WriteString("\x1b[" + strings.Join(params, ";") + "m")
+		}
+
+		if run.style.url != url {
+			url = run.style.url
+			buf.WriteString("\x1b]8;;" + url + "\x1b\\")
+		}
+
+		buf.WriteString(run.text)
+	}
+
+	if url != "" {
+		buf.WriteString("\x1b]8;;\x1b\\")
+	}
+
+	buf.WriteString("\x1b[0m")
+
+	return buf.String()
+}
+
+// Returns the inline CSS for a style, or "" if it has none.
+func cssStyle(fc formatCode) string {
+	defaults := newFormatCode()
+	fg, bg := fc.fg, fc.bg
+
+	if fc.attrs&tcell.AttrReverse != 0 {
+		fg, bg = bg, fg
+	} else {
+		if fg == defaults.fg {
+			fg = tcell.ColorDefault
+		}
+
+		if bg == defaults.bg {
+			bg = tcell.ColorDefault
+		}
+	}
+
+	css := []string{}
+
+	if fg.Valid() {
+		css = append(css, "color: "+fg.CSS())
+	}
+
+	if bg.Valid() {
+		css = append(css, "background-color: "+bg.CSS())
+	}
+
+	if fc.attrs&tcell.AttrBold != 0 {
+		css = append(css, "font-weight: bold")
+	}
+
+	if fc.attrs&tcell.AttrDim != 0 {
+		css = append(css, "opacity: 0.5")
+	}
+
+	if fc.attrs&tcell.AttrItalic != 0 {
+		css = append(css, "font-style: italic")
+	}
+
+	decorations := []string{}
+
+	if fc.attrs&tcell.AttrUnderline != 0 {
+		decorations = append(decorations, "underline")
+	}
+
+	if fc.attrs&tcell.AttrStrikeThrough != 0 {
+		decorations = append(decorations, "line-through")
+	}
+
+	if fc.attrs&tcell.AttrBlink != 0 {
+		decorations = append(decorations, "blink")
+	}
+
+	if len(decorations) > 0 {
+		css = append(css, "text-decoration: "+strings.Join(decorations, " "))
+	}
+
+	return strings.Join(css, "; ")
+}
+
+// Returns the runs as an HTML <pre> element.
+func runsToHTML(runs []styledText) string {
+	if len(runs) == 0 {
+		return ""
+	}
+
+	buf := strings.Builder{}
+	buf.WriteString("<pre>")
+
+	for _, run := range runs {
+		text := html.EscapeString(run.text)
+
+		if run.style.url != "" {
+			text = `<a href="` + html.EscapeString(run.style.url) + `">` + text + "</a>"
+		}
+
+		if css := cssStyle(run.style); css != "" {
+			text = `<span style="` + css + `">` + text + "</span>"
+		}
+
+		buf.WriteString(text)
+	}
+
+	buf.WriteString("</pre>")
+
+	return buf.String()
+}
+
+// Characters that are escaped in Markdown text.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "~", `\~`, "<", `\<`,
+)
+
+// Matches text at the start of a line that Markdown would read as a block
+// marker: a heading, list item, block quote, thematic break or setext heading
+// underline, capturing the number of an ordered list item. Code fences start
+// with characters that are escaped anyway.
+var markdownBlockMarker = regexp.MustCompile(`^(?:[#>+=-]|([0-9]{1,9})[.)])`)
+
+// Escapes a block marker at the start of text that starts a line.
+func escapeBlockMarker(text string) string {
+	match := markdownBlockMarker.FindStringSubmatchIndex(text)
+
+	switch {
+	case match == nil:
+		return text
+	case match[2] >= 0:
+		return text[:match[3]] + `\` + text[match[3]:]
+	default:
+		return `\` + text
+	}
+}
+
+// Returns a URL as a Markdown link destination, in angle brackets if it
+// contains characters that would end it.
+func markdownURL(url string) string {
+	if !strings.ContainsAny(url, " ()<>") {
+		return url
+	}
+
+	return "<" + strings.NewReplacer("<", `\<`, ">", `\>`).Replace(url) + ">"
+}
+
+// Returns the runs as Markdown. Bold, italic and strikethrough text and links
+// are kept; other styles have no Markdown equivalent.
+func runsToMarkdown(runs []styledText) string {
+	buf := strings.Builder{}
+
+	// The emphasis and link open in the current line, innermost last, and
+	// the whitespace written since the last text, which closing markers must
+	// precede, since emphasis can't end with whitespace
+	type marker struct {
+		open, close string
+	}
+
+	open := []marker{}
+	space := ""
+	lineStart := true
+
+	closeTo := func(n int) {
+		for len(open) > n {
+			buf.WriteString(open[len(open)-1].close)
+			open = open[:len(open)-1]
+		}
+	}
+
+	for _, run := range runs {
+		// The markers this run needs, outermost first
+		wanted := []marker{}
+
+		if run.style.url != "" {
+			wanted = append(wanted, marker{"[", "](" + markdownURL(run.style.url) + ")"})
+		}
+
+		if run.style.attrs&tcell.AttrBold != 0 {
+			wanted = append(wanted, marker{"**", "**"})
+		}
+
+		if run.style.attrs&tcell.AttrItalic != 0 {
+			wanted = append(wanted, marker{"_", "_"})
+		}
+
+		if run.style.attrs&tcell.AttrStrikeThrough != 0 {
+			wanted = append(wanted, marker{"~~", "~~"})
+		}
+
+		// Emphasis can't span lines, so markers are closed at the end of each
+		// line and opened again on the next.
+		for i, line := range strings.Split(run.text, "\n") {
+			if i > 0 {
+				closeTo(0)
+				buf.WriteString(space + "\n")
+				space = ""
+				lineStart = true
+			}
+
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" {
+				space += line
+				continue
+			}
+
+			// Keep the markers that are still wanted open, and close the
+			// rest along with any opened after them
+			keep := 0
+			for keep < len(open) && slices.Contains(wanted, open[keep]) {
+				keep++
+			}
+
+			closeTo(keep)
+
+			start := strings.Index(line, trimmed)
+			buf.WriteString(space + line[:start])
+
+			for _, m := range wanted {
+				if !slices.Contains(open, m) {
+					buf.WriteString(m.open)
+					open = append(open, m)
+					lineStart = false
+				}
+			}
+
+			// Text that starts a line must not start a block
+			escaped := markdownEscaper.Replace(trimmed)
+			if lineStart {
+				escaped = escapeBlockMarker(escaped)
+			}
+
+			buf.WriteString(escaped)
+			lineStart = false
+			space = line[start+len(trimmed):]
+		}
+	}
+
+	closeTo(0)
+	buf.WriteString(space)
+
+	return buf.String()
+}
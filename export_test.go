@@ -0,0 +1,79 @@
+package textsel
+
+import "testing"
+
+func TestGetSelectedTextAs(t *testing.T) {
+	ts := NewTextSel().SetText("[red]a*[::b]b c[-::i] d[::-]\n[#ff8000:blue]x[-:-][:::https://x.org]lnk[:::-] [::s]s")
+
+	for _, format := range []SelectionFormat{FormatPlain, FormatTview, FormatANSI, FormatHTML, FormatMarkdown} {
+		if got := ts.GetSelectedTextAs(format); got != "" {
+			t.Errorf("GetSelectedTextAs(%s) without a selection failed. Expected '', got %q", format, got)
+		}
+	}
+
+	ts.StartSelection().MoveToLastLine().MoveToEndOfLine()
+
+	tests := []struct {
+		format   SelectionFormat
+		expected string
+	}{
+		{FormatPlain, "a*b c d\nxlnk s"},
+		{FormatTview, "[red:black:-:-]a*[red:black:-:-][::b]b c[white:black:-:-][::bi] d[white:black:-:-]\n[#FF8000:blue:-:-]x[white:black:-:https://x.org]lnk[white:black:-:-] [white:black:-:-][::s]s[-:-:-:-]"},
+		{FormatANSI, "\x1b[0;91ma*\x1b[0;91;1mb c\x1b[0;1;3m d\x1b[0m\n\x1b[0;38;2;255;128;0;104mx\x1b[0m\x1b]8;;https://x.org\x1b\\lnk\x1b[0m\x1b]8;;\x1b\\ \x1b[0;9ms\x1b[0m"},
+		{FormatHTML, `<pre><span style="color: #FF0000">a*</span><span style="color: #FF0000; font-weight: bold">b c</span><span style="font-weight: bold; font-style: italic"> d</span>` + "\n" + `<span style="color: #FF8000; background-color: #0000FF">x</span><a href="https://x.org">lnk</a> <span style="text-decoration: line-through">s</span></pre>`},
+		{FormatMarkdown, "a\\***b c _d_**\nx[lnk](https://x.org) ~~s~~"},
+	}
+
+	for _, test := range tests {
+		if got := ts.GetSelectedTextAs(test.format); got != test.expected {
+			t.Errorf("GetSelectedTextAs(%s) failed. Expected %q, got %q", test.format, test.expected, got)
+		}
+	}
+
+	// Markers stay open across runs that keep them, and URLs are escaped
+	markdownTests := []struct {
+		text     string
+		expected string
+	}{
+		{"[::b]ab[::i]cd", "**ab_cd_**"},
+		{"[::bi]ab[::I]cd[::B] e", "**_ab_cd** e"},
+		{"[::b]a [::i]b[::I] c", "**a _b_ c**"},
+		{"[::s]a\nb", "~~a~~\n~~b~~"},
+		{"[:::https://x.org/a_(b) c]lnk[:::-] [:::https://x.org][::b]x", "[lnk](<https://x.org/a_(b) c>) [**x**](https://x.org)"},
+		{"# a\n- b\n  + c\n12. d\n3) e\n> f\n```\n---", "\\# a\n\\- b\n  \\+ c\n12\\. d\n3\\) e\n\\> f\n\\`\\`\\`\n\\---"},
+		{"a # b - c\n[red]-[-] d 1. e", "a # b - c\n\\- d 1. e"},
+		{"[::b]# a[::B]\n12ab", "**# a**\n12ab"},
+	}
+
+	for _, test := range markdownTests {
+		ts := NewTextSel().SetText(test.text)
+		ts.StartSelection().MoveToLastLine().MoveToEndOfLine()
+
+		if got := ts.GetSelectedTextAs(FormatMarkdown); got != test.expected {
+			t.Errorf("GetSelectedTextAs(FormatMarkdown) for %q failed. Expected %q, got %q", test.text, test.expected, got)
+		}
+	}
+}
+
+func TestANSIColor(t *testing.T) {
+	tests := []struct {
+		color      string
+		background bool
+		expected   string
+	}{
+		{"maroon", false, "31"},
+		{"red", false, "91"},
+		{"navy", true, "44"},
+		{"white", true, "107"},
+		{"#102030", false, "38;2;16;32;48"},
+		{"default", false, ""},
+	}
+
+	for _, test := range tests {
+		color := newFormatCode().update("[" + test.color + "]").fg
+
+		if got := ansiColor(color, test.background); got != test.expected {
+			t.Errorf("ansiColor(%s) failed. Expected %q, got %q", test.color, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package textsel
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// HelpView is a `tview.Primitive` listing the key bindings of a TextSel's
+// keymap along with the descriptions of the actions they are bound to. The
+// list is regenerated whenever it is drawn, so it always reflects the current
+// keymap, even if it is changed or replaced while the help is displayed.
+type HelpView struct {
+	*tview.Table
+
+	ts       *TextSel
+	doneFunc func()
+}
+
+// Creates a help view for the key bindings of ts.
+func newHelpView(ts *TextSel) *HelpView {
+	hv := &HelpView{
+		Table: tview.NewTable().SetFixed(1, 0),
+		ts:    ts,
+	}
+
+	hv.SetBorder(true).SetTitle(" Key bindings ")
+	hv.refresh()
+
+	return hv
+}
+
+// SetDoneFunc sets the callback function that will be called when the user
+// closes the help view with Escape, q, ? or F1.
+//
+// Example:
+//
+//	textSel.GetHelpView().SetDoneFunc(func() {
+//		pages.RemovePage("help")
+//		app.SetFocus(textSel)
+//	})
+func (hv *HelpView) SetDoneFunc(f func()) *HelpView {
+	hv.doneFunc = f
+	return hv
+}
+
+// Draw regenerates the list of key bindings and draws the help view.
+func (hv *HelpView) Draw(screen tcell.Screen) {
+	hv.refresh()
+	hv.Table.Draw(screen)
+}
+
+// InputHandler closes the help view on Escape, q, ? or F1 and passes all other
+// keys to the table, which uses them to scroll.
+func (hv *HelpView) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return hv.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		switch KeyName(event) {
+		case "Esc", "q", "?", "F1":
+			if hv.doneFunc != nil {
+				hv.doneFunc()
+			}
+
+			return
+		}
+
+		if handler := hv.Table.InputHandler(); handler != nil {
+			handler(event, setFocus)
+		}
+	})
+}
+
+// Fills the table with one row per action and mode, listing all keys bound to
+// the action in that mode.
+func (hv *HelpView) refresh() {
+	hv.Clear()
+
+	header := func(col int, text string) {
+		hv.SetCell(0, col, tview.NewTableCell(text).
+			SetAttributes(tcell.AttrBold).
+			SetSelectable(false))
+	}
+
+	header(0, "Keys")
+	header(1, "Action")
+	header(2, "Mode")
+
+	row := 0
+	last := Binding{Mode: AnyMode}
+	keys := []string{}
+
+	for _, binding := range hv.ts.GetKeymap().Bindings() {
+		if row > 0 && binding.Action == last.Action && binding.Mode == last.Mode {
+			keys = append(keys, binding.Keys)
+		} else {
+			row++
+			keys = []string{binding.Keys}
+		}
+
+		description := binding.Action
+		if action, ok := hv.ts.GetAction(binding.Action); ok && action.Description != "" {
+			description = action.Description
+		}
+
+		mode := ""
+		if binding.Mode != AnyMode {
+			mode = binding.Mode.String()
+		}
+
+		hv.SetCell(row, 0, tview.NewTableCell(tview.Escape(strings.Join(keys, ", "))))
+		hv.SetCell(row, 1, tview.NewTableCell(tview.Escape(description)).SetExpansion(1))
+		hv.SetCell(row, 2, tview.NewTableCell(mode))
+
+		last = binding
+	}
+}
+
+// GetHelpView returns the help view listing the widget's key bindings. The
+// same instance is returned on every call.
+func (ts *TextSel) GetHelpView() *HelpView {
+	if ts.helpView == nil {
+		ts.helpView = newHelpView(ts)
+	}
+
+	return ts.helpView
+}
+
+// SetHelpFunc sets the callback function that will be called when the user
+// asks for help (with ? or F1 in the default keymap). The callback receives
+// the help view, which the host can display in a modal, a page or a flex
+// layout.
+//
+// Example:
+//
+//	textSel.SetHelpFunc(func(help *textsel.HelpView) {
+//		pages.AddPage("help", help, true, true)
+//		app.SetFocus(help)
+//	})
+func (ts *TextSel) SetHelpFunc(f func(help *HelpView)) *TextSel {
+	ts.helpFunc = f
+	return ts
+}
+
+// ShowHelp passes the help view to the help callback (see SetHelpFunc).
+func (ts *TextSel) ShowHelp() *TextSel {
+	if ts.helpFunc != nil {
+		ts.helpFunc(ts.GetHelpView())
+	}
+
+	return ts
+}
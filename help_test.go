@@ -0,0 +1,83 @@
+package textsel
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Returns the rows of the help view as [keys, action, mode] triples.
+func helpRows(hv *HelpView) [][3]string {
+	rows := [][3]string{}
+
+	for row := 1; row < hv.GetRowCount(); row++ {
+		rows = append(rows, [3]string{
+			hv.GetCell(row, 0).Text,
+			hv.GetCell(row, 1).Text,
+			hv.GetCell(row, 2).Text,
+		})
+	}
+
+	return rows
+}
+
+func TestHelpView(t *testing.T) {
+	ts := NewTextSel().SetKeymap(NewKeymap().
+		Bind("k", ActionMoveUp).
+		Bind("Up", ActionMoveUp).
+		BindMode(ModeVisualChar, "y", ActionFinishSelection).
+		Bind("[", "custom"))
+
+	hv := ts.GetHelpView()
+
+	expected := [][3]string{
+		{"[", "custom", ""},
+		{"y", "Finish the selection", "visual"},
+		{"Up, k", "Move the cursor up", ""},
+	}
+
+	got := helpRows(hv)
+	if len(got) != len(expected) {
+		t.Fatalf("HelpView failed. Expected %v, got %v", expected, got)
+	}
+
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("HelpView failed. Expected %v, got %v", expected[i], got[i])
+		}
+	}
+
+	// Changes to the keymap show up the next time the view is drawn
+	ts.GetKeymap().Unbind("Up")
+	ts.RegisterAction("custom", "Do something", nil)
+
+	screen := tcell.NewSimulationScreen("")
+	screen.Init()
+	hv.SetRect(0, 0, 80, 10)
+	hv.Draw(screen)
+
+	if got := helpRows(hv); len(got) != 3 || got[0][1] != "Do something" || got[2][0] != "k" {
+		t.Errorf("HelpView did not update. Got %v", got)
+	}
+}
+
+func TestShowHelp(t *testing.T) {
+	var shown *HelpView
+	var done bool
+
+	ts := NewTextSel().SetKeymap(VimKeymap()).SetHelpFunc(func(help *HelpView) { shown = help })
+
+	ts.GetHelpView().SetDoneFunc(func() { done = true })
+	ts.handleKeyEvents(runeKey('?'))
+
+	if shown == nil || shown != ts.GetHelpView() {
+		t.Fatal("'?' did not show the help view")
+	}
+
+	shown.InputHandler()(tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone), func(p tview.Primitive) {})
+
+	if !done {
+		t.Error("Escape did not close the help view")
+	}
+}
@@ -4,13 +4,19 @@ import "strings"
 
 // Highlights the cursor position and selected text in the widget.
 func (ts *TextSel) highlightCursor() {
-	text := ts.text
-	startRow, startCol, endRow, endCol := ts.GetSelectionRange()
+	tokens := splitTags(ts.text)
+	lines := ts.getGraphemeLines()
+	_, _, endRow, _ := ts.GetSelectionRange()
+	blockMode := ts.selectionMode() == ModeVisualBlock
 
 	buf := strings.Builder{}
+	out := newTagWriter(&buf)
 	sel := false
+	selRow := -1
+	selFirst, selLast, rowSelected := 0, 0, false
 	row := 0
 	col := 0
+	cell := 0
 
 	// We only highlight the cursor if the widget has focus
 	showCursor := ts.HasFocus()
@@ -19,50 +25,121 @@ func (ts *TextSel) highlightCursor() {
 	// highlight the cursor and selection.
 	formatCode := newFormatCode()
 
-	for idx := 0; idx < len(text); idx++ {
-		// Check for the start of a format code using the regex. Do so until we
-		// get all of the ones at the current index.
-		for formatRegex.MatchString(text[idx:]) {
-			match := formatRegex.FindString(text[idx:])
-
-			// If we are selecting, we skip the format code, because it may
-			// interfere with the selection format. Otherwise, we write it to
-			// the buffer.
-			if !sel {
-				// Write the format code unchanged to the buffer
-				buf.WriteString(match)
+	// With bidi support, lines containing right-to-left text are displayed in
+	// visual order. The output for each character of such a line is collected
+	// separately, starting with the complete style at that character, and
+	// written in visual order at the end of the line, with characters that
+	// are displayed right to left mirrored.
+	var order, levels []int
+	var segments []*tagWriter
+
+	// Returns the complete style at the current position.
+	currentStyle := func() string {
+		if sel {
+			return ts.selectionStyle(formatCode)
+		}
+
+		return formatCode.String()
+	}
+
+	// Writes the collected characters of a line in visual order.
+	flush := func() {
+		out = newTagWriter(&buf)
+
+		for _, c := range order {
+			if c < len(segments) {
+				out.WriteTag(segments[c].String())
+			}
+		}
+
+		if segments != nil {
+			out.WriteTag(currentStyle())
+		}
+
+		segments = nil
+	}
+
+	for idx := 0; idx < len(tokens); {
+		// Handle all of the tags at the current index.
+		for ; idx < len(tokens) && tokens[idx].isTag(); idx++ {
+			tag := tokens[idx].raw
+
+			// Region tags don't affect the style, so we always keep them.
+			if tokens[idx].isRegionTag() {
+				out.WriteTag(tag)
+				continue
 			}
 
 			// Parse the format code into its components and save them
 			// for later use.
-			formatCode = formatCode.update(match)
+			formatCode = formatCode.update(tag)
 
-			// Adjust index to skip the matched format code
-			idx += len(match)
-
-			if idx >= len(text) {
-				break
+			// If we are selecting, the format code would override the
+			// selection background, so we write the selection style for the
+			// new format instead. Otherwise, we write it unchanged.
+			if sel {
+				out.WriteTag(ts.selectionStyle(formatCode))
+			} else {
+				out.WriteTag(tag)
 			}
 		}
 
-		// Now that we've dealt with any leading format tags, we can get the
-		// current character.
-		char := text[idx]
+		// The text may end with format codes
+		if idx >= len(tokens) {
+			break
+		}
+
+		// Now that we've dealt with any leading tags, we can get the current
+		// character, which is a whole grapheme cluster.
+		char := tokens[idx].char
+		idx++
+		isNewline := isLineEnding(char)
+		width := ts.cellWidth(char, cell)
+
+		// Expand tabs to the next tab stop, so that they are displayed with
+		// the configured width.
+		display := char
+		if char == "\t" {
+			display = strings.Repeat(" ", width)
+		}
 
-		// Mark the start of the selection
-		isSelStartRow := row == startRow
-		isSelStartCol := col == startCol
+		// Determine which columns of the current row are selected, and the
+		// order in which they are displayed
+		if row != selRow {
+			selRow = row
+			selFirst, selLast, rowSelected = 0, 0, false
+			order, levels = nil, nil
 
-		// If the cursor moves up or down from a column > 0, but the current
-		// line is empty, pretend the cursor is on the first column.
-		if !isSelStartCol && char == '\n' && col == 0 && startCol > 0 {
-			isSelStartCol = true
+			if row < len(lines) {
+				selFirst, selLast, rowSelected = ts.selectedColumns(row, lines[row])
+				order, levels = ts.bidiLayout(lines[row])
+			}
 		}
 
-		// If this is the beginning of the selection, mark it
-		if ts.isSelecting && isSelStartRow && isSelStartCol {
+		if order != nil {
+			if col < len(levels) {
+				display = mirrorAtLevel(display, levels[col])
+			}
+
+			if isNewline {
+				flush()
+			} else {
+				segment := newTagWriter(&strings.Builder{})
+				segment.WriteTag(currentStyle())
+				segments = append(segments, segment)
+				out = segment
+			}
+		}
+
+		// The selection ends on the last selected character of the selection,
+		// or of each row in block mode.
+		isSelEnd := rowSelected && col == selLast && (blockMode || row == endRow)
+
+		// If this is the beginning of the selection (or of its part in the
+		// current row), mark it
+		if !sel && rowSelected && col >= selFirst && col <= selLast {
 			sel = true
-			buf.WriteString(ts.selectionColor)
+			out.WriteTag(ts.selectionStyle(formatCode))
 		}
 
 		// Determine if the cursor is on the current character
@@ -71,7 +148,7 @@ func (ts *TextSel) highlightCursor() {
 
 		// If the cursor moves up or down from a column > 0, but the current
 		// line is empty, pretend the cursor is on the first column.
-		if !isCursorCol && char == '\n' && col == 0 && ts.cursorCol > 0 {
+		if !isCursorCol && isNewline && col == 0 && ts.cursorCol > 0 {
 			isCursorCol = true
 		}
 
@@ -83,51 +160,58 @@ func (ts *TextSel) highlightCursor() {
 			if sel {
 				cursorStart = ts.cursorInSelectionColor
 
-				if ts.cursorRow != endRow || ts.cursorCol != endCol {
-					// If the cursor is NOT at the end of the selection, the
-					// selection color should be used to "reset" the format.
-					cursorEnd = ts.selectionColor
-				} else {
-					// If we ARE in the middle of a selection, we need to use
-					// a slightly modified version of the current format string,
-					// to remove the in-selection styles used to highlight the
-					// cursor.
-					cursorEnd = formatCode.update("[::-]").String()
+				// If the cursor is NOT at the end of the selection, the
+				// selection style should be used to "reset" the format.
+				if !isSelEnd {
+					cursorEnd = ts.selectionStyle(formatCode)
 				}
 			}
 
-			buf.WriteString(cursorStart)
+			out.WriteTag(cursorStart)
 
-			// If the cursor is on an empty line ("\n"), add a space to make it
-			// visible.
-			if char == '\n' {
-				buf.WriteString(" \n")
+			// If the cursor is on a line ending, add a space to make it
+			// visible. All line endings are displayed as "\n".
+			if isNewline {
+				out.WriteText(" \n")
 			} else {
-				buf.WriteString(string(char))
+				out.WriteText(display)
 			}
 
-			buf.WriteString(cursorEnd)
-		} else if char == '\n' {
+			out.WriteTag(cursorEnd)
+		} else if isNewline {
 			// If the cursor is not on the current character, but it's a newline,
 			// we need to add a space to make it visible.
-			buf.WriteString(" \n")
+			out.WriteText(" \n")
 		} else {
-			buf.WriteString(string(char))
+			out.WriteText(display)
 		}
 
 		// Mark the end of the selection
-		if sel && row == endRow && col == endCol {
+		if sel && isSelEnd {
 			sel = false
-			buf.WriteString(formatCode.String())
+			out.WriteTag(formatCode.String())
 		}
 
-		if char == '\n' {
+		if isNewline {
 			row++
 			col = 0
+			cell = 0
 		} else {
 			col++
+			cell += width
 		}
 	}
 
+	flush()
+
 	ts.TextView.SetText(buf.String())
+
+	// Highlight the region under the cursor, if requested
+	if ts.highlightRegion {
+		if id := ts.RegionAtCursor(); id != "" {
+			ts.Highlight(id)
+		} else {
+			ts.Highlight()
+		}
+	}
 }
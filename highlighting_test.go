@@ -36,7 +36,7 @@ func TestHighlightCursor(t *testing.T) {
 
 	// Test Case 1: Cursor at the beginning
 	ts.SetText("Hello World").ResetCursor().highlightCursor()
-	expectedOutput1 := "[black:white:-]H[white:black:]ello World"
+	expectedOutput1 := "[black:white:-]H[white:black:-:-]ello World"
 	actualOutput1 := ts.TextView.GetText(false)
 
 	if actualOutput1 != expectedOutput1 {
@@ -54,7 +54,7 @@ func TestHighlightCursor(t *testing.T) {
 		highlightCursor()
 
 	// note extra space at end of line; it is used to make the cursor visible
-	expectedOutput2 := "Hello \nWorl[black:white:-]d[white:black:]"
+	expectedOutput2 := "Hello \nWorl[black:white:-]d[white:black:-:-]"
 	actualOutput2 := ts.TextView.GetText(false)
 
 	if actualOutput2 != expectedOutput2 {
@@ -73,10 +73,80 @@ func TestHighlightCursor(t *testing.T) {
 		highlightCursor()
 
 	// note extra space at end of line; it is used to make the cursor visible
-	expectedOutput3 := "[black:yellow:-]Hello[black:yellow:bu] \n[white:black:-][white:black:]World"
+	expectedOutput3 := "[black:yellow:-:-]Hello[black:yellow:bu] \n[white:black:-:-][white:black:-:-]World"
 	actualOutput3 := ts.TextView.GetText(false)
 
 	if actualOutput3 != expectedOutput3 {
 		t.Errorf("Selection highlight failed.\n\nExpected: '%s'\n\n  Actual: '%s'\n\n", visualizeString(expectedOutput3), visualizeString(actualOutput3))
 	}
 }
+
+func TestHighlightUnicode(t *testing.T) {
+	ts := NewTextSel()
+
+	app := tview.NewApplication()
+	app.SetRoot(ts, true)
+	app.SetFocus(ts)
+
+	ts.SetText("héllo 👍🏽\nwörld").SetCursorPosition(0, 1)
+
+	expected := "h[black:white:-]é[white:black:-:-]llo 👍🏽 \nwörld"
+	if got := ts.TextView.GetText(false); got != expected {
+		t.Errorf("Unicode cursor highlight failed.\n\nExpected: '%s'\n\n  Actual: '%s'\n\n", visualizeString(expected), visualizeString(got))
+	}
+
+	ts.SetCursorPosition(0, 6)
+
+	expected = "héllo [black:white:-]👍🏽[white:black:-:-] \nwörld"
+	if got := ts.TextView.GetText(false); got != expected {
+		t.Errorf("Emoji cursor highlight failed.\n\nExpected: '%s'\n\n  Actual: '%s'\n\n", visualizeString(expected), visualizeString(got))
+	}
+
+	// Format codes at the very end of the text
+	ts.SetText("ü[red]").SetCursorPosition(0, 0)
+
+	expected = "[black:white:-]ü[white:black:-:-][red]"
+	if got := ts.TextView.GetText(false); got != expected {
+		t.Errorf("Trailing format code highlight failed.\n\nExpected: '%s'\n\n  Actual: '%s'\n\n", visualizeString(expected), visualizeString(got))
+	}
+}
+
+func TestHighlightLineEndings(t *testing.T) {
+	ts := NewTextSel()
+
+	app := tview.NewApplication()
+	app.SetRoot(ts, true)
+	app.SetFocus(ts)
+
+	ts.SetText("ab\r\ncd\ref").SetCursorPosition(1, 2)
+
+	expected := "ab \ncd[black:white:-] \n[white:black:-:-]ef"
+	if got := ts.TextView.GetText(false); got != expected {
+		t.Errorf("Line ending highlight failed.\n\nExpected: '%s'\n\n  Actual: '%s'\n\n", visualizeString(expected), visualizeString(got))
+	}
+}
+
+func TestHighlightSelectionStyles(t *testing.T) {
+	ts := NewTextSel()
+
+	app := tview.NewApplication()
+	app.SetRoot(ts, true)
+	app.SetFocus(ts)
+
+	ts.SetText("[red]ab[::b]cd[-:-:-]ef")
+	ts.SetCursorPosition(0, 1).StartSelection().SetCursorPosition(0, 4).highlightCursor()
+
+	// Selected text keeps its foreground and attributes
+	expected := "[red]a[red:yellow:-:-]b[red:yellow:-:-][::b]cd[black:yellow:-:-][black:yellow:bu]e[white:black:-:-][white:black:-:-]f"
+	if got := ts.TextView.GetText(false); got != expected {
+		t.Errorf("Layered selection highlight failed.\n\nExpected: '%s'\n\n  Actual: '%s'\n\n", visualizeString(expected), visualizeString(got))
+	}
+
+	// Without preserving styles, the selection colors replace them
+	ts.SetPreserveSelectionStyles(false)
+
+	expected = "[red]a[black:yellow:-]b[black:yellow:-]cd[black:yellow:-][black:yellow:bu]e[white:black:-:-][white:black:-:-]f"
+	if got := ts.TextView.GetText(false); got != expected {
+		t.Errorf("Replaced selection highlight failed.\n\nExpected: '%s'\n\n  Actual: '%s'\n\n", visualizeString(expected), visualizeString(got))
+	}
+}
@@ -0,0 +1,525 @@
+package textsel
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Keymap maps key sequences to the names of actions (see Execute and
+// RegisterAction).
+//
+// A key sequence is a space-separated list of key names. Printable keys are
+// named by their character (e.g. "k", "$" or "G"), with the exception of the
+// space bar, which is named "Space". Other keys use the names from
+// `tcell.KeyNames` (e.g. "Up", "Enter", "PgDn", "F1" or "Ctrl-N"). Modifiers
+// are written as prefixes, e.g. "Alt-f" or "Shift-Left". Key names are not
+// case sensitive except for single characters, so "ctrl-n" is the same as
+// "Ctrl-N".
+//
+// Bindings either apply in every mode (Bind) or in a single mode (BindMode).
+// Bindings for the current mode take precedence. In ModeSearch, only bindings
+// for that mode apply, so that all other keys can be typed into the query.
+//
+// If counts are enabled (see SetCounts), digits typed before a key sequence
+// are a count that repeats its action, e.g. "3 j" moves down three lines.
+type Keymap struct {
+	bindings map[Mode]map[string]string
+	counts   bool
+}
+
+// Binding is a single entry in a Keymap.
+type Binding struct {
+	Keys   string
+	Action string
+
+	// The mode the binding applies in, or AnyMode
+	Mode Mode
+}
+
+// NewKeymap creates a new, empty Keymap.
+func NewKeymap() *Keymap {
+	return &Keymap{bindings: map[Mode]map[string]string{}}
+}
+
+// DefaultKeymap returns a new Keymap with the default bindings. It is also
+// available as the "default" preset (see KeymapByName); the "vim" preset (see
+// VimKeymap) adds words, visual modes, search, yanking and more.
+//
+//	Up, k        move up
+//	Down, j      move down
+//	Left, h      move left
+//	Right, l     move right
+//	^            move to the start of the line
+//	$            move to the end of the line
+//	Space        start selecting
+//	Enter        finish the selection
+func DefaultKeymap() *Keymap {
+	return NewKeymap().
+		Bind("Up", ActionMoveUp).
+		Bind("k", ActionMoveUp).
+		Bind("Down", ActionMoveDown).
+		Bind("j", ActionMoveDown).
+		Bind("Left", ActionMoveLeft).
+		Bind("h", ActionMoveLeft).
+		Bind("Right", ActionMoveRight).
+		Bind("l", ActionMoveRight).
+		Bind("^", ActionMoveToStartOfLine).
+		Bind("$", ActionMoveToEndOfLine).
+		Bind("Space", ActionStartSelection).
+		Bind("Enter", ActionFinishSelection)
+}
+
+// VimKeymap returns a new Keymap with vim-like bindings. It is also available
+// as the "vim" preset (see KeymapByName).
+//
+//	Up, k        move up
+//	Down, j      move down
+//	Left, h      move left
+//	Right, l     move right
+//	w            move to the next word
+//	b            move to the previous word
+//	e            move to the end of the word
+//	^            move to the start of the line
+//	$            move to the end of the line
+//	Space, v     start selecting (in visual mode, v cancels)
+//	V            start selecting lines
+//	Ctrl-V       start selecting a block
+//	/            search
+//	n, N         search forward, backward
+//	y            yank the following motion (yy yanks the line; in visual
+//	             mode, yanks the selection)
+//	i w          select the word under the cursor (in visual mode, or after y)
+//	Esc          cancel the selection (in visual mode)
+//	Enter        finish the selection
+//	|            pipe the selection (see SetPipeCommand)
+//	?, F1        show the key bindings (see SetHelpFunc)
+//	1-9          count for the following keys, e.g. 3 j (see SetCounts)
+func VimKeymap() *Keymap {
+	return NewKeymap().
+		SetCounts(true).
+		Bind("Up", ActionMoveUp).
+		Bind("k", ActionMoveUp).
+		Bind("Down", ActionMoveDown).
+		Bind("j", ActionMoveDown).
+		Bind("Left", ActionMoveLeft).
+		Bind("h", ActionMoveLeft).
+		Bind("Right", ActionMoveRight).
+		Bind("l", ActionMoveRight).
+		Bind("w", ActionMoveWordForward).
+		Bind("b", ActionMoveWordBackward).
+		Bind("e", ActionMoveWordEnd).
+		Bind("^", ActionMoveToStartOfLine).
+		Bind("$", ActionMoveToEndOfLine).
+		Bind("Space", ActionStartSelection).
+		Bind("v", ActionStartSelection).
+		Bind("V", ActionStartLineSelection).
+		Bind("Ctrl-V", ActionStartBlockSelection).
+		Bind("/", ActionStartSearch).
+		Bind("n", ActionSearchNext).
+		Bind("N", ActionSearchPrev).
+		Bind("] r", ActionNextRegion).
+		Bind("[ r", ActionPrevRegion).
+		Bind("] c", ActionNextCodeBlock).
+		Bind("[ c", ActionPrevCodeBlock).
+		Bind("] b", ActionNextBlock).
+		Bind("[ b", ActionPrevBlock).
+		Bind("Enter", ActionFinishSelection).
+		Bind("|", ActionPipeSelection).
+		Bind("?", ActionShowHelp).
+		Bind("F1", ActionShowHelp).
+		BindMode(ModeNormal, "y", ActionYank).
+		BindMode(ModePendingOperator, "y", ActionYankLine).
+		BindMode(ModeVisualChar, "Esc", ActionResetSelection).
+		BindMode(ModeVisualLine, "Esc", ActionResetSelection).
+		BindMode(ModeVisualBlock, "Esc", ActionResetSelection).
+		BindMode(ModeVisualChar, "v", ActionResetSelection).
+		BindMode(ModeVisualChar, "y", ActionFinishSelection).
+		BindMode(ModeVisualLine, "y", ActionFinishSelection).
+		BindMode(ModeVisualBlock, "y", ActionFinishSelection).
+		BindMode(ModePendingOperator, "i w", ActionSelectInnerWord).
+		BindMode(ModeVisualChar, "i w", ActionSelectInnerWord).
+		BindMode(ModeVisualLine, "i w", ActionSelectInnerWord).
+		BindMode(ModeVisualBlock, "i w", ActionSelectInnerWord).
+		BindMode(ModePendingOperator, "i r", ActionSelectRegion).
+		BindMode(ModeVisualChar, "i r", ActionSelectRegion).
+		BindMode(ModeVisualLine, "i r", ActionSelectRegion).
+		BindMode(ModeVisualBlock, "i r", ActionSelectRegion).
+		BindMode(ModePendingOperator, "i c", ActionSelectCodeBlock).
+		BindMode(ModeVisualChar, "i c", ActionSelectCodeBlock).
+		BindMode(ModeVisualLine, "i c", ActionSelectCodeBlock).
+		BindMode(ModeVisualBlock, "i c", ActionSelectCodeBlock).
+		BindMode(ModePendingOperator, "a c", ActionSelectCodeBlockAll).
+		BindMode(ModeVisualChar, "a c", ActionSelectCodeBlockAll).
+		BindMode(ModeVisualLine, "a c", ActionSelectCodeBlockAll).
+		BindMode(ModeVisualBlock, "a c", ActionSelectCodeBlockAll).
+		BindMode(ModePendingOperator, "i b", ActionSelectBlock).
+		BindMode(ModeVisualChar, "i b", ActionSelectBlock).
+		BindMode(ModeVisualLine, "i b", ActionSelectBlock).
+		BindMode(ModeVisualBlock, "i b", ActionSelectBlock)
+}
+
+// Bind binds a key sequence to an action in every mode, replacing any
+// existing binding for the same sequence.
+//
+// Example:
+//
+//	keymap.Bind("g g", textsel.ActionMoveToFirstLine)
+func (km *Keymap) Bind(keys string, action string) *Keymap {
+	return km.BindMode(AnyMode, keys, action)
+}
+
+// BindMode binds a key sequence to an action in a single mode, replacing any
+// existing binding for the same sequence and mode.
+//
+// Example:
+//
+//	keymap.BindMode(textsel.ModeVisualChar, "y", textsel.ActionFinishSelection)
+func (km *Keymap) BindMode(mode Mode, keys string, action string) *Keymap {
+	if km.bindings[mode] == nil {
+		km.bindings[mode] = map[string]string{}
+	}
+
+	km.bindings[mode][normalizeKeys(keys)] = action
+	return km
+}
+
+// Unbind removes the binding for a key sequence that applies in every mode,
+// if there is one.
+//
+// Example:
+//
+//	textSel.GetKeymap().Unbind("h").Unbind("l")
+func (km *Keymap) Unbind(keys string) *Keymap {
+	return km.UnbindMode(AnyMode, keys)
+}
+
+// UnbindMode removes the binding for a key sequence in a single mode, if
+// there is one.
+func (km *Keymap) UnbindMode(mode Mode, keys string) *Keymap {
+	delete(km.bindings[mode], normalizeKeys(keys))
+	return km
+}
+
+// Lookup returns the action bound to a key sequence in every mode.
+func (km *Keymap) Lookup(keys string) (string, bool) {
+	return km.LookupMode(AnyMode, keys)
+}
+
+// LookupMode returns the action bound to a key sequence in the given mode,
+// falling back to the bindings for every mode (except in ModeSearch).
+func (km *Keymap) LookupMode(mode Mode, keys string) (string, bool) {
+	return km.lookup(mode, normalizeKeys(keys))
+}
+
+// Bindings returns all bindings in the keymap, sorted by action, then by mode
+// and then by key sequence.
+func (km *Keymap) Bindings() []Binding {
+	bindings := []Binding{}
+
+	for mode, modeBindings := range km.bindings {
+		for keys, action := range modeBindings {
+			bindings = append(bindings, Binding{Keys: keys, Action: action, Mode: mode})
+		}
+	}
+
+	sort.Slice(bindings, func(i, j int) bool {
+		if bindings[i].Action != bindings[j].Action {
+			return bindings[i].Action < bindings[j].Action
+		}
+
+		if bindings[i].Mode != bindings[j].Mode {
+			return bindings[i].Mode < bindings[j].Mode
+		}
+
+		return bindings[i].Keys < bindings[j].Keys
+	})
+
+	return bindings
+}
+
+// Clone returns a copy of the keymap that can be modified independently.
+func (km *Keymap) Clone() *Keymap {
+	clone := NewKeymap().SetCounts(km.counts)
+
+	for mode, modeBindings := range km.bindings {
+		for keys, action := range modeBindings {
+			clone.BindMode(mode, keys, action)
+		}
+	}
+
+	return clone
+}
+
+// SetCounts sets whether digits typed before a key sequence are a count that
+// is passed to its action, as in vim. A count typed before an operator
+// multiplies the count of the motion that follows it. Digits bound in the
+// keymap are not counts, except for 0 following another digit. Counts are off
+// in a new Keymap.
+//
+// Example:
+//
+//	keymap := textsel.DefaultKeymap().SetCounts(true)
+func (km *Keymap) SetCounts(counts bool) *Keymap {
+	km.counts = counts
+	return km
+}
+
+// Returns the action bound to an already normalized key sequence.
+func (km *Keymap) lookup(mode Mode, keys string) (string, bool) {
+	if action, ok := km.bindings[mode][keys]; ok {
+		return action, true
+	}
+
+	if mode == ModeSearch {
+		return "", false
+	}
+
+	action, ok := km.bindings[AnyMode][keys]
+	return action, ok
+}
+
+// Returns true if the key sequence is a proper prefix of a sequence bound in
+// the given mode.
+func (km *Keymap) isPrefix(mode Mode, keys string) bool {
+	modes := []Mode{mode}
+	if mode != ModeSearch {
+		modes = append(modes, AnyMode)
+	}
+
+	for _, m := range modes {
+		for bound := range km.bindings[m] {
+			if strings.HasPrefix(bound, keys+" ") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// SetKeymap sets the keymap used to handle key events. Pass nil to disable all
+// key bindings.
+//
+// Example:
+//
+//	keymap := textsel.DefaultKeymap().Bind("g g", textsel.ActionMoveToFirstLine)
+//	textSel.SetKeymap(keymap)
+func (ts *TextSel) SetKeymap(keymap *Keymap) *TextSel {
+	if keymap == nil {
+		keymap = NewKeymap()
+	}
+
+	ts.keymap = keymap
+	ts.pendingKeys = nil
+	ts.count = 0
+
+	return ts
+}
+
+// GetKeymap returns the keymap used to handle key events. Changes to the
+// returned keymap take effect immediately.
+func (ts *TextSel) GetKeymap() *Keymap {
+	return ts.keymap
+}
+
+// KeyName returns the name of the key pressed in a key event, in the notation
+// used by Keymap.
+func KeyName(event *tcell.EventKey) string {
+	if event.Key() == tcell.KeyRune {
+		name := string(event.Rune())
+
+		if event.Rune() == ' ' {
+			name = "Space"
+		}
+
+		if event.Modifiers()&tcell.ModAlt != 0 {
+			name = "Alt-" + name
+		}
+
+		return name
+	}
+
+	name, ok := tcell.KeyNames[event.Key()]
+	if !ok {
+		return event.Name()
+	}
+
+	mods := event.Modifiers()
+
+	if mods&tcell.ModShift != 0 {
+		name = "Shift-" + name
+	}
+
+	if mods&tcell.ModAlt != 0 {
+		name = "Alt-" + name
+	}
+
+	if mods&tcell.ModCtrl != 0 && !strings.HasPrefix(name, "Ctrl-") {
+		name = "Ctrl-" + name
+	}
+
+	return name
+}
+
+// Canonical spelling of every named key and modifier, indexed by its lower
+// case form.
+var keyNames = func() map[string]string {
+	names := map[string]string{
+		"space": "Space",
+		"ctrl":  "Ctrl",
+		"alt":   "Alt",
+		"shift": "Shift",
+	}
+
+	for _, name := range tcell.KeyNames {
+		names[strings.ToLower(name)] = name
+	}
+
+	return names
+}()
+
+// Brings a key sequence into canonical form: single spaces between keys and
+// the canonical spelling of each named key.
+func normalizeKeys(keys string) string {
+	fields := strings.Fields(keys)
+
+	for i, key := range fields {
+		fields[i] = normalizeKey(key)
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// Brings a single key name into canonical form.
+func normalizeKey(key string) string {
+	if name, ok := keyNames[strings.ToLower(key)]; ok {
+		return name
+	}
+
+	// Split off modifier prefixes, taking care not to split "Ctrl--" or
+	// "Alt--" in the wrong place.
+	mods := map[string]bool{}
+	for {
+		idx := strings.Index(key, "-")
+		if idx <= 0 || idx == len(key)-1 {
+			break
+		}
+
+		mod, ok := keyNames[strings.ToLower(key[:idx])]
+		if !ok || (mod != "Ctrl" && mod != "Alt" && mod != "Shift") {
+			break
+		}
+
+		mods[mod] = true
+		key = key[idx+1:]
+	}
+
+	if len(mods) == 0 {
+		return key
+	}
+
+	if name, ok := keyNames[strings.ToLower(key)]; ok {
+		key = name
+	}
+
+	// "Ctrl-N" and friends are key names of their own
+	if mods["Ctrl"] {
+		if name, ok := keyNames[strings.ToLower("Ctrl-"+key)]; ok {
+			key = name
+			delete(mods, "Ctrl")
+		}
+	}
+
+	// Modifiers are always listed in the same order as KeyName does.
+	for _, mod := range []string{"Shift", "Alt", "Ctrl"} {
+		if mods[mod] {
+			key = mod + "-" + key
+		}
+	}
+
+	return key
+}
+
+// Handles a key event according to the keymap. Returns true if the key was
+// bound to an action or is part of a bound key sequence.
+func (ts *TextSel) dispatchKey(event *tcell.EventKey) bool {
+	if ts.isCountDigit(event) {
+		ts.count = ts.count*10 + int(event.Rune()-'0')
+		return true
+	}
+
+	keys := strings.Join(append(ts.pendingKeys, KeyName(event)), " ")
+
+	if action, ok := ts.keymap.lookup(ts.mode, keys); ok {
+		ts.pendingKeys = nil
+		ts.executeWithCount(action)
+		return true
+	}
+
+	if ts.keymap.isPrefix(ts.mode, keys) {
+		ts.pendingKeys = strings.Split(keys, " ")
+		return true
+	}
+
+	handled := len(ts.pendingKeys) > 0
+	ts.pendingKeys = nil
+	ts.count = 0
+
+	if !handled && ts.mode == ModeSearch {
+		handled = ts.handleSearchInput(event)
+	}
+
+	// Any key that is not a motion cancels a pending operator
+	if !handled && ts.mode == ModePendingOperator {
+		ts.cancelOperator()
+		handled = true
+	}
+
+	return handled
+}
+
+// Returns true if the key is a digit of a count typed before a key sequence
+// (see Keymap.SetCounts).
+func (ts *TextSel) isCountDigit(event *tcell.EventKey) bool {
+	if !ts.keymap.counts || ts.mode == ModeSearch || len(ts.pendingKeys) > 0 {
+		return false
+	}
+
+	if event.Key() != tcell.KeyRune || event.Modifiers() != tcell.ModNone {
+		return false
+	}
+
+	r := event.Rune()
+	if r == '0' && ts.count > 0 {
+		return true
+	}
+
+	if r < '1' || r > '9' {
+		return false
+	}
+
+	key := KeyName(event)
+	_, bound := ts.keymap.lookup(ts.mode, key)
+
+	return !bound && !ts.keymap.isPrefix(ts.mode, key)
+}
+
+// Executes an action bound to a key sequence with the count typed before it.
+// The count typed before an operator is kept for the motion that follows it.
+func (ts *TextSel) executeWithCount(action string) {
+	pending := ts.mode == ModePendingOperator
+
+	count := max(ts.count, 1)
+	if pending {
+		count *= ts.operatorCount
+	}
+
+	ts.count = 0
+	ts.reportError(ts.Execute(action, count))
+
+	if !pending && ts.mode == ModePendingOperator {
+		ts.operatorCount = count
+	}
+}
@@ -0,0 +1,220 @@
+package textsel
+
+import "sort"
+
+// The keymap presets available through KeymapByName.
+var keymapPresets = map[string]func() *Keymap{
+	"default": DefaultKeymap,
+	"vim":     VimKeymap,
+	"emacs":   EmacsKeymap,
+	"less":    LessKeymap,
+	"tmux":    TmuxKeymap,
+}
+
+// KeymapByName returns a new instance of the named keymap preset: "default",
+// "vim", "emacs", "less" or "tmux". The second return value is false if
+// there is no preset with that name.
+//
+// Example:
+//
+//	if keymap, ok := textsel.KeymapByName(config.Keys); ok {
+//		textSel.SetKeymap(keymap)
+//	}
+func KeymapByName(name string) (*Keymap, bool) {
+	preset, ok := keymapPresets[name]
+	if !ok {
+		return nil, false
+	}
+
+	return preset(), true
+}
+
+// KeymapNames returns the names of all keymap presets in alphabetical order.
+func KeymapNames() []string {
+	names := make([]string, 0, len(keymapPresets))
+
+	for name := range keymapPresets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// EmacsKeymap returns a new Keymap with Emacs-style bindings.
+//
+//	C-p, Up          move up
+//	C-n, Down        move down
+//	C-b, Left        move left
+//	C-f, Right       move right
+//	M-b              move to the previous word
+//	M-f              move to the end of the word
+//	C-a, Home        move to the start of the line
+//	C-e, End         move to the end of the line
+//	M-<              move to the first line
+//	M->              move to the last line
+//	C-v, PgDn        move down one page
+//	M-v, PgUp        move up one page
+//	C-SPC            set the mark (start selecting)
+//	M-w, Enter       copy the region (finish the selection)
+//	C-s              search forward (again, while searching)
+//	C-g              quit the search or deactivate the mark
+//	F1               show the key bindings
+func EmacsKeymap() *Keymap {
+	return NewKeymap().
+		Bind("Ctrl-P", ActionMoveUp).
+		Bind("Up", ActionMoveUp).
+		Bind("Ctrl-N", ActionMoveDown).
+		Bind("Down", ActionMoveDown).
+		Bind("Ctrl-B", ActionMoveLeft).
+		Bind("Left", ActionMoveLeft).
+		Bind("Ctrl-F", ActionMoveRight).
+		Bind("Right", ActionMoveRight).
+		Bind("Alt-b", ActionMoveWordBackward).
+		Bind("Alt-f", ActionMoveWordEnd).
+		Bind("Ctrl-A", ActionMoveToStartOfLine).
+		Bind("Home", ActionMoveToStartOfLine).
+		Bind("Ctrl-E", ActionMoveToEndOfLine).
+		Bind("End", ActionMoveToEndOfLine).
+		Bind("Alt-<", ActionMoveToFirstLine).
+		Bind("Alt->", ActionMoveToLastLine).
+		Bind("Ctrl-V", ActionMovePageDown).
+		Bind("PgDn", ActionMovePageDown).
+		Bind("Alt-v", ActionMovePageUp).
+		Bind("PgUp", ActionMovePageUp).
+		Bind("Ctrl-Space", ActionStartSelection).
+		Bind("Alt-w", ActionFinishSelection).
+		Bind("Enter", ActionFinishSelection).
+		Bind("Ctrl-S", ActionStartSearch).
+		BindMode(ModeSearch, "Ctrl-S", ActionSearchNext).
+		Bind("Ctrl-G", ActionCancel).
+		BindMode(ModeSearch, "Ctrl-G", ActionCancel).
+		Bind("F1", ActionShowHelp)
+}
+
+// LessKeymap returns a new Keymap with the paging keys of `less`.
+//
+//	k, y, C-p, Up              move up
+//	j, e, C-n, Down            move down
+//	h, Left                    move left
+//	l, Right                   move right
+//	f, Space, C-f, C-v, PgDn   move down one page
+//	b, C-b, M-v, PgUp          move up one page
+//	d, C-d                     move down half a page
+//	u, C-u                     move up half a page
+//	g, <, Home                 move to the first line
+//	G, >, End                  move to the last line
+//	/                          search forward
+//	n, N                       repeat the search forward, backward
+//	m                          start selecting
+//	Enter                      finish the selection
+//	Esc                        reset the selection
+//	H, F1                      show the key bindings
+//	1-9                        count for the following keys, e.g. 3 j
+//
+// Because the space bar pages down in `less`, selections are started with m
+// (for "mark") instead.
+func LessKeymap() *Keymap {
+	return NewKeymap().
+		SetCounts(true).
+		Bind("k", ActionMoveUp).
+		Bind("y", ActionMoveUp).
+		Bind("Ctrl-P", ActionMoveUp).
+		Bind("Up", ActionMoveUp).
+		Bind("j", ActionMoveDown).
+		Bind("e", ActionMoveDown).
+		Bind("Ctrl-N", ActionMoveDown).
+		Bind("Down", ActionMoveDown).
+		Bind("h", ActionMoveLeft).
+		Bind("Left", ActionMoveLeft).
+		Bind("l", ActionMoveRight).
+		Bind("Right", ActionMoveRight).
+		Bind("f", ActionMovePageDown).
+		Bind("Space", ActionMovePageDown).
+		Bind("Ctrl-F", ActionMovePageDown).
+		Bind("Ctrl-V", ActionMovePageDown).
+		Bind("PgDn", ActionMovePageDown).
+		Bind("b", ActionMovePageUp).
+		Bind("Ctrl-B", ActionMovePageUp).
+		Bind("Alt-v", ActionMovePageUp).
+		Bind("PgUp", ActionMovePageUp).
+		Bind("d", ActionMoveHalfPageDown).
+		Bind("Ctrl-D", ActionMoveHalfPageDown).
+		Bind("u", ActionMoveHalfPageUp).
+		Bind("Ctrl-U", ActionMoveHalfPageUp).
+		Bind("g", ActionMoveToFirstLine).
+		Bind("<", ActionMoveToFirstLine).
+		Bind("Home", ActionMoveToFirstLine).
+		Bind("G", ActionMoveToLastLine).
+		Bind(">", ActionMoveToLastLine).
+		Bind("End", ActionMoveToLastLine).
+		Bind("/", ActionStartSearch).
+		Bind("n", ActionSearchNext).
+		Bind("N", ActionSearchPrev).
+		Bind("m", ActionStartSelection).
+		Bind("Enter", ActionFinishSelection).
+		Bind("Esc", ActionResetSelection).
+		Bind("H", ActionShowHelp).
+		Bind("F1", ActionShowHelp)
+}
+
+// TmuxKeymap returns a new Keymap compatible with the default bindings of
+// tmux's copy-mode-vi.
+//
+//	k, Up                 move up
+//	j, Down               move down
+//	h, Left               move left
+//	l, Right              move right
+//	w                     move to the next word
+//	b                     move to the previous word
+//	e                     move to the end of the word
+//	0, ^                  move to the start of the line
+//	$                     move to the end of the line
+//	g                     move to the first line
+//	G                     move to the last line
+//	C-f, PgDn             move down one page
+//	C-b, PgUp             move up one page
+//	C-d                   move down half a page
+//	C-u                   move up half a page
+//	/                     search forward
+//	n, N                  repeat the search forward, backward
+//	Space                 begin the selection
+//	Enter                 copy the selection
+//	Esc, q                clear the selection
+//	F1                    show the key bindings
+//	1-9                   count for the following keys, e.g. 3 j
+func TmuxKeymap() *Keymap {
+	return NewKeymap().
+		SetCounts(true).
+		Bind("k", ActionMoveUp).
+		Bind("Up", ActionMoveUp).
+		Bind("j", ActionMoveDown).
+		Bind("Down", ActionMoveDown).
+		Bind("h", ActionMoveLeft).
+		Bind("Left", ActionMoveLeft).
+		Bind("l", ActionMoveRight).
+		Bind("Right", ActionMoveRight).
+		Bind("w", ActionMoveWordForward).
+		Bind("b", ActionMoveWordBackward).
+		Bind("e", ActionMoveWordEnd).
+		Bind("0", ActionMoveToStartOfLine).
+		Bind("^", ActionMoveToStartOfLine).
+		Bind("$", ActionMoveToEndOfLine).
+		Bind("g", ActionMoveToFirstLine).
+		Bind("G", ActionMoveToLastLine).
+		Bind("Ctrl-F", ActionMovePageDown).
+		Bind("PgDn", ActionMovePageDown).
+		Bind("Ctrl-B", ActionMovePageUp).
+		Bind("PgUp", ActionMovePageUp).
+		Bind("Ctrl-D", ActionMoveHalfPageDown).
+		Bind("Ctrl-U", ActionMoveHalfPageUp).
+		Bind("/", ActionStartSearch).
+		Bind("n", ActionSearchNext).
+		Bind("N", ActionSearchPrev).
+		Bind("Space", ActionStartSelection).
+		Bind("Enter", ActionFinishSelection).
+		Bind("Esc", ActionResetSelection).
+		Bind("q", ActionResetSelection).
+		Bind("F1", ActionShowHelp)
+}
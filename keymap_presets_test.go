@@ -0,0 +1,111 @@
+package textsel
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestKeymapByName(t *testing.T) {
+	for _, name := range KeymapNames() {
+		keymap, ok := KeymapByName(name)
+		if !ok || keymap == nil {
+			t.Errorf("KeymapByName(%q) failed", name)
+		}
+	}
+
+	if _, ok := KeymapByName("nano"); ok {
+		t.Error("KeymapByName returned a preset for an unknown name")
+	}
+
+	// Presets are independent copies
+	a, _ := KeymapByName("vim")
+	b, _ := KeymapByName("vim")
+	a.Unbind("k")
+
+	if _, ok := b.Lookup("k"); !ok {
+		t.Error("Modifying a preset affected another instance")
+	}
+}
+
+func TestEmacsKeymap(t *testing.T) {
+	ts := NewTextSel().SetText("Hello, World!\nFoo").SetKeymap(EmacsKeymap())
+
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyCtrlSpace, 0, tcell.ModCtrl))
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModAlt))
+
+	var selected string
+	ts.SetSelectFunc(func(text string) { selected = text })
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyRune, 'w', tcell.ModAlt))
+
+	if selected != "Hello" {
+		t.Errorf("Emacs keymap failed to copy the region. Expected 'Hello', got: '%s'", selected)
+	}
+
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyCtrlN, 0, tcell.ModCtrl))
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyCtrlE, 0, tcell.ModCtrl))
+
+	row, col := ts.GetCursorPosition()
+	if row != 1 || col != 2 {
+		t.Errorf("Emacs keymap failed. Expected cursorRow = 1, cursorCol = 2, got = %d, %d", row, col)
+	}
+
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyCtrlS, 0, tcell.ModCtrl))
+
+	if mode := ts.GetMode(); mode != ModeSearch {
+		t.Errorf("Emacs keymap failed to start the search. Expected mode %v, got %v", ModeSearch, mode)
+	}
+
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyCtrlG, 0, tcell.ModCtrl))
+
+	if mode := ts.GetMode(); mode != ModeNormal {
+		t.Errorf("Emacs keymap failed to quit the search. Expected mode %v, got %v", ModeNormal, mode)
+	}
+}
+
+func TestLessKeymap(t *testing.T) {
+	ts := NewTextSel().SetText("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n").SetKeymap(LessKeymap())
+	ts.SetRect(0, 0, 10, 4)
+
+	ts.handleKeyEvents(runeKey(' '))
+
+	if row, _ := ts.GetCursorPosition(); row != 4 {
+		t.Errorf("Less keymap failed to page down. Expected cursorRow = 4, got = %d", row)
+	}
+
+	ts.handleKeyEvents(runeKey('d'))
+
+	if row, _ := ts.GetCursorPosition(); row != 6 {
+		t.Errorf("Less keymap failed to page down half a page. Expected cursorRow = 6, got = %d", row)
+	}
+
+	ts.handleKeyEvents(runeKey('G'))
+
+	if row, _ := ts.GetCursorPosition(); row != 9 {
+		t.Errorf("Less keymap failed to move to the last line. Expected cursorRow = 9, got = %d", row)
+	}
+
+	ts.handleKeyEvents(runeKey('b'))
+
+	if row, _ := ts.GetCursorPosition(); row != 5 {
+		t.Errorf("Less keymap failed to page up. Expected cursorRow = 5, got = %d", row)
+	}
+}
+
+func TestTmuxKeymap(t *testing.T) {
+	ts := NewTextSel().SetText("foo bar baz").SetKeymap(TmuxKeymap())
+
+	ts.handleKeyEvents(runeKey('w'))
+	ts.handleKeyEvents(runeKey(' '))
+	ts.handleKeyEvents(runeKey('e'))
+
+	if got := ts.GetSelectedText(); got != "bar" {
+		t.Errorf("Tmux keymap failed to select. Expected 'bar', got: '%s'", got)
+	}
+
+	ts.handleKeyEvents(runeKey('q'))
+
+	if ts.isSelecting() {
+		t.Error("Tmux keymap failed to clear the selection")
+	}
+}
@@ -0,0 +1,196 @@
+package textsel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func runeKey(r rune) *tcell.EventKey {
+	return tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)
+}
+
+func TestKeyName(t *testing.T) {
+	tests := []struct {
+		event    *tcell.EventKey
+		expected string
+	}{
+		{runeKey('k'), "k"},
+		{runeKey('G'), "G"},
+		{runeKey(' '), "Space"},
+		{tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModAlt), "Alt-f"},
+		{tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone), "Up"},
+		{tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), "Enter"},
+		{tcell.NewEventKey(tcell.KeyCtrlN, 0, tcell.ModCtrl), "Ctrl-N"},
+		{tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModShift), "Shift-Left"},
+		{tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModCtrl|tcell.ModAlt), "Ctrl-Alt-Up"},
+	}
+
+	for _, test := range tests {
+		if got := KeyName(test.event); got != test.expected {
+			t.Errorf("KeyName(%s) failed. Expected '%s', got '%s'", test.event.Name(), test.expected, got)
+		}
+	}
+}
+
+func TestNormalizeKeys(t *testing.T) {
+	tests := map[string]string{
+		"k":              "k",
+		"  g   g ":       "g g",
+		"space":          "Space",
+		"ctrl-n":         "Ctrl-N",
+		"CTRL-space":     "Ctrl-Space",
+		"alt-f":          "Alt-f",
+		"alt--":          "Alt--",
+		"-":              "-",
+		"alt-ctrl-up":    "Ctrl-Alt-Up",
+		"shift-alt-pgdn": "Alt-Shift-PgDn",
+	}
+
+	for keys, expected := range tests {
+		if got := normalizeKeys(keys); got != expected {
+			t.Errorf("normalizeKeys(%q) failed. Expected '%s', got '%s'", keys, expected, got)
+		}
+	}
+}
+
+func TestDefaultKeymap(t *testing.T) {
+	ts := NewTextSel().SetText("Hello\nWorld")
+
+	ts.handleKeyEvents(runeKey('j'))
+	ts.handleKeyEvents(runeKey('l'))
+
+	row, col := ts.GetCursorPosition()
+	if row != 1 || col != 1 {
+		t.Errorf("Default keymap failed. Expected cursorRow = 1, cursorCol = 1, got = %d, %d", row, col)
+	}
+
+	ts.handleKeyEvents(runeKey(' '))
+	ts.handleKeyEvents(runeKey('$'))
+
+	if got := ts.GetSelectedText(); got != "orld" {
+		t.Errorf("Default keymap failed to select. Expected 'orld', got: '%s'", got)
+	}
+}
+
+func TestDefaultKeymapLeavesVimKeys(t *testing.T) {
+	var unhandled []string
+
+	ts := NewTextSel().SetText("Hello\nWorld").
+		SetUnhandledKeyFunc(func(event *tcell.EventKey) *tcell.EventKey {
+			unhandled = append(unhandled, KeyName(event))
+			return nil
+		})
+
+	for _, r := range "nNy?bwevV/3" {
+		ts.InputHandler()(runeKey(r), nil)
+	}
+
+	if got := strings.Join(unhandled, ""); got != "nNy?bwevV/3" {
+		t.Errorf("Default keymap consumed keys it should not bind. Expected 'nNy?bwevV/3' unhandled, got '%s'", got)
+	}
+}
+
+func TestUnbind(t *testing.T) {
+	ts := NewTextSel().SetText("Hello")
+	ts.GetKeymap().Unbind("l")
+
+	ts.handleKeyEvents(runeKey('l'))
+
+	if _, col := ts.GetCursorPosition(); col != 0 {
+		t.Errorf("Unbound key moved the cursor. Expected cursorCol = 0, got = %d", col)
+	}
+
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModNone))
+
+	if _, col := ts.GetCursorPosition(); col != 1 {
+		t.Errorf("Other bindings were affected by Unbind. Expected cursorCol = 1, got = %d", col)
+	}
+
+	ts.SetKeymap(nil)
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyRight, 0, tcell.ModNone))
+
+	if _, col := ts.GetCursorPosition(); col != 1 {
+		t.Errorf("Empty keymap moved the cursor. Expected cursorCol = 1, got = %d", col)
+	}
+}
+
+func TestKeySequences(t *testing.T) {
+	ts := NewTextSel().SetText("Hello\nWorld\nFoo")
+	ts.SetKeymap(NewKeymap().
+		Bind("g g", ActionMoveToFirstLine).
+		Bind("G", ActionMoveToLastLine))
+
+	ts.handleKeyEvents(runeKey('G'))
+
+	if row, _ := ts.GetCursorPosition(); row != 2 {
+		t.Errorf("Single key binding failed. Expected cursorRow = 2, got = %d", row)
+	}
+
+	if !ts.dispatchKey(runeKey('g')) {
+		t.Error("Prefix of a key sequence was not consumed")
+	}
+
+	if row, _ := ts.GetCursorPosition(); row != 2 {
+		t.Errorf("Prefix of a key sequence moved the cursor. Expected cursorRow = 2, got = %d", row)
+	}
+
+	ts.handleKeyEvents(runeKey('g'))
+
+	if row, _ := ts.GetCursorPosition(); row != 0 {
+		t.Errorf("Key sequence failed. Expected cursorRow = 0, got = %d", row)
+	}
+
+	// An incomplete sequence followed by an unbound key is discarded
+	ts.handleKeyEvents(runeKey('G'))
+	ts.handleKeyEvents(runeKey('g'))
+	ts.handleKeyEvents(runeKey('x'))
+	ts.handleKeyEvents(runeKey('g'))
+
+	if row, _ := ts.GetCursorPosition(); row != 2 {
+		t.Errorf("Broken key sequence moved the cursor. Expected cursorRow = 2, got = %d", row)
+	}
+}
+
+func TestCounts(t *testing.T) {
+	var selected string
+
+	ts := NewTextSel().SetKeymap(TmuxKeymap()).
+		SetText("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12").
+		SetSelectFunc(func(text string) { selected = text })
+
+	keys := func(runes string) {
+		for _, r := range runes {
+			ts.handleKeyEvents(runeKey(r))
+		}
+	}
+
+	// 0 is bound, but continues a count
+	keys("10j")
+
+	if row, _ := ts.GetCursorPosition(); row != 10 {
+		t.Errorf("Count failed. Expected cursorRow = 10, got = %d", row)
+	}
+
+	keys("0")
+
+	if row, _ := ts.GetCursorPosition(); row != 10 {
+		t.Errorf("Bound 0 failed after a count. Expected cursorRow = 10, got = %d", row)
+	}
+
+	// Counts before an operator and its motion multiply
+	ts.SetKeymap(VimKeymap()).SetText("a b c d e f g h")
+	keys("2y3w")
+
+	if selected != "a b c d e f " {
+		t.Errorf("Count with an operator failed. Expected 'a b c d e f ', got '%s'", selected)
+	}
+
+	// Without counts, digits are not consumed
+	ts.SetKeymap(VimKeymap().SetCounts(false))
+
+	if ts.dispatchKey(runeKey('3')) {
+		t.Errorf("Digit was consumed with counts off")
+	}
+}
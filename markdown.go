@@ -0,0 +1,560 @@
+package textsel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
+)
+
+// Matches an ATX heading, capturing its level and text.
+var markdownHeading = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+
+// Matches a list item marker, capturing the indentation, the marker and the
+// spaces after it.
+var markdownListItem = regexp.MustCompile(`^([ \t]*)([-*+]|[0-9]{1,9}[.)])([ \t]+)`)
+
+// Matches an opening or closing code fence, capturing the fence.
+var markdownFence = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+
+// Matches an autolink, capturing the URL.
+var markdownAutolink = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]*:[^<>\s]+)>`)
+
+// Renders Markdown as text with tview tags, mapping the rendered text to the
+// Markdown it was rendered from.
+type markdownRenderer struct {
+	*sourceMap
+
+	source string
+
+	// The fence of the code block being rendered, if any
+	fence string
+
+	// The current style, and the styles of the spans enclosing it
+	style  formatCode
+	styles []formatCode
+}
+
+// Renders the Markdown source and returns the renderer, which keeps the
+// source map.
+func renderMarkdown(source string) (*markdownRenderer, string) {
+	r := &markdownRenderer{sourceMap: newSourceMap(), source: source, style: newFormatCode()}
+
+	for offset := 0; offset < len(source); {
+		end := len(source)
+		if i := strings.IndexByte(source[offset:], '\n'); i >= 0 {
+			end = offset + i + 1
+		}
+
+		line := strings.TrimRight(source[offset:end], "\r\n")
+		r.line(line, offset)
+
+		if lineEnd := offset + len(line); lineEnd < end {
+			r.text("\n", lineEnd, end)
+		}
+
+		offset = end
+	}
+
+	return r, r.take()
+}
+
+// Returns the Markdown source of the ranges of the rendered text. The parts
+// of a block selection are joined by newlines.
+func (r *markdownRenderer) original(ranges [][2]int) (string, bool) {
+	if !r.covers(ranges) {
+		return "", false
+	}
+
+	parts := []string{}
+	for _, rng := range ranges {
+		parts = append(parts, r.source[r.starts[rng[0]]:r.ends[rng[1]-1]])
+	}
+
+	return strings.Join(parts, "\n"), true
+}
+
+// Starts a span with the style given by a tag, on top of the current style.
+func (r *markdownRenderer) push(tag string) {
+	r.styles = append(r.styles, r.style)
+	r.style = r.style.update(tag)
+	r.tag(tag)
+}
+
+// Ends the innermost span, restoring the style enclosing it rather than
+// turning its attributes off, which could be set by an enclosing span too.
+// Colors the Markdown didn't set are reset, leaving them to the text view.
+func (r *markdownRenderer) pop() {
+	r.style = r.styles[len(r.styles)-1]
+	r.styles = r.styles[:len(r.styles)-1]
+
+	restore := r.style
+	initial := newFormatCode()
+
+	fg, bg, url := "-", "-", "-"
+	if restore.fg != initial.fg {
+		fg = restore.fg.String()
+	}
+	if restore.bg != initial.bg {
+		bg = restore.bg.String()
+	}
+	if restore.url != "" {
+		url = restore.url
+	}
+
+	tag := fmt.Sprintf("[%s:%s:-:%s]", fg, bg, url)
+	if flags := restore.flags(); flags != "" {
+		tag += "[::" + flags + "]"
+	}
+
+	r.tag(tag)
+}
+
+// Renders a line of Markdown without its line ending, which starts at the
+// given offset of the source.
+func (r *markdownRenderer) line(line string, offset int) {
+	code := tview.Styles.SecondaryTextColor.String()
+
+	// Code blocks are displayed as they are, with dimmed fences
+	if fence := markdownFence.FindStringSubmatch(line); fence != nil {
+		if r.fence == "" || (fence[1][0] == r.fence[0] && len(fence[1]) >= len(r.fence) && strings.TrimSpace(line[len(fence[0]):]) == "") {
+			if r.fence == "" {
+				r.fence = fence[1]
+			} else {
+				r.fence = ""
+			}
+
+			r.push("[::d]")
+			r.verbatim(line, offset)
+			r.pop()
+
+			return
+		}
+	}
+
+	if r.fence != "" {
+		r.push("[" + code + "]")
+		r.verbatim(line, offset)
+		r.pop()
+
+		return
+	}
+
+	// Block quotes are displayed with a bar for each level
+	for {
+		trimmed := strings.TrimLeft(line, " ")
+		if !strings.HasPrefix(trimmed, ">") || len(line)-len(trimmed) > 3 {
+			break
+		}
+
+		marker := len(line) - len(trimmed) + 1
+		if strings.HasPrefix(trimmed, "> ") {
+			marker++
+		}
+
+		r.push("[::d]")
+		r.text("│ ", offset, offset+marker)
+		r.pop()
+
+		line, offset = line[marker:], offset+marker
+	}
+
+	if match := markdownHeading.FindStringSubmatchIndex(line); match != nil {
+		style := "[::b]"
+		if match[3]-match[2] == 1 {
+			style = "[::bu]"
+		}
+
+		from := len(r.starts)
+
+		if match[4] >= 0 {
+			r.push(style)
+			r.inline(line[match[4]:match[5]], offset+match[4])
+			r.pop()
+		}
+
+		r.extend(from, len(r.starts), offset, offset+len(line))
+
+		return
+	}
+
+	if isThematicBreak(line) {
+		r.push("[::d]")
+		for i := 0; i < uniseg.StringWidth(line); i++ {
+			r.text("─", offset, offset+len(line))
+		}
+		r.pop()
+
+		return
+	}
+
+	if match := markdownListItem.FindStringSubmatchIndex(line); match != nil {
+		r.verbatim(line[:match[3]], offset)
+
+		marker := line[match[4]:match[5]]
+		if strings.ContainsAny(marker, "-*+") {
+			marker = "•"
+		}
+
+		r.text(marker+" ", offset+match[4], offset+match[1])
+		line, offset = line[match[1]:], offset+match[1]
+	}
+
+	r.inline(line, offset)
+}
+
+// Returns true if the line is a thematic break, e.g. "---" or "* * *".
+func isThematicBreak(line string) bool {
+	trimmed := strings.ReplaceAll(strings.TrimSpace(line), " ", "")
+	if len(trimmed) < 3 || len(line)-len(strings.TrimLeft(line, " ")) > 3 {
+		return false
+	}
+
+	return strings.Count(trimmed, trimmed[:1]) == len(trimmed) && strings.Contains("-*_", trimmed[:1])
+}
+
+// Writes text as it is, mapping each character to itself in the source.
+func (r *markdownRenderer) verbatim(text string, offset int) {
+	for i := 0; i < len(text); {
+		char, _, _, _ := uniseg.FirstGraphemeClusterInString(text[i:], -1)
+		r.text(char, offset+i, offset+i+len(char))
+		i += len(char)
+	}
+}
+
+// A part of a line of inline Markdown: a character, a code span, a link, an
+// autolink, or a run of emphasis delimiters.
+type inlineNode struct {
+	kind       inlineKind
+	start, end int
+
+	// The text displayed for a character, or the label of a link and the
+	// offset at which it starts, and the URL of a link or autolink
+	text       string
+	textOffset int
+	url        string
+
+	// For delimiter runs: the delimiter, whether the run can open or close
+	// emphasis, the number of delimiters used from the left by closing and
+	// from the right by opening emphasis, the number of emphasis spans it
+	// closes and the tags of those it opens, and the length of the displayed
+	// text where emphasis ends and starts
+	delim             byte
+	canOpen, canClose bool
+	left, right       int
+	closes            int
+	openTags          []string
+	closePos, openPos int
+	inactive          bool
+}
+
+// The kinds of inline nodes.
+type inlineKind int
+
+const (
+	inlineChar inlineKind = iota
+	inlineCode
+	inlineLink
+	inlineAutolink
+	inlineDelimiters
+)
+
+// Returns the number of delimiters of a run that are not used yet.
+func (n *inlineNode) remaining() int {
+	return n.end - n.start - n.left - n.right
+}
+
+// Emphasis found between two delimiter runs, and the range of the delimiters
+// used for it.
+type emphasis struct {
+	opener, closer *inlineNode
+	start, end     int
+}
+
+// Renders inline Markdown: code spans, emphasis, strikethrough, links and
+// backslash escapes. Emphasis follows the CommonMark rules for delimiter runs,
+// so that e.g. the underscores in foo_bar_baz are displayed as they are.
+func (r *markdownRenderer) inline(text string, offset int) {
+	nodes := parseInline(text)
+	matches := matchEmphasis(nodes)
+	code := tview.Styles.SecondaryTextColor.String()
+
+	for _, n := range nodes {
+		from := len(r.starts)
+
+		switch n.kind {
+		case inlineChar:
+			r.text(n.text, offset+n.start, offset+n.end)
+		case inlineCode:
+			r.push("[" + code + "]")
+			r.verbatim(n.text, offset+n.textOffset)
+			r.pop()
+			r.extend(from, len(r.starts), offset+n.start, offset+n.end)
+		case inlineLink:
+			if !strings.ContainsAny(n.url, "[] ") {
+				r.push("[::u:" + n.url + "]")
+			} else {
+				r.push("[::u]")
+			}
+
+			r.inline(n.text, offset+n.textOffset)
+			r.pop()
+			r.extend(from, len(r.starts), offset+n.start, offset+n.end)
+		case inlineAutolink:
+			r.push("[::u:" + n.url + "]")
+			r.verbatim(n.url, offset+n.textOffset)
+			r.pop()
+			r.extend(from, len(r.starts), offset+n.start, offset+n.end)
+		case inlineDelimiters:
+			n.closePos = len(r.starts)
+			for range n.closes {
+				r.pop()
+			}
+
+			r.verbatim(text[n.start+n.left:n.end-n.right], offset+n.start+n.left)
+			n.openPos = len(r.starts)
+
+			for _, tag := range n.openTags {
+				r.push(tag)
+			}
+		}
+	}
+
+	// The source of emphasized text includes its delimiters
+	for _, m := range matches {
+		r.extend(m.opener.openPos, m.closer.closePos, offset+m.start, offset+m.end)
+	}
+}
+
+// Splits a line of inline Markdown into nodes.
+func parseInline(text string) []*inlineNode {
+	nodes := []*inlineNode{}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+
+		// Backslash escapes
+		if rest[0] == '\\' && len(rest) > 1 && strings.IndexByte("\\`*_{}[]()#+-.!~<>|", rest[1]) >= 0 {
+			nodes = append(nodes, &inlineNode{kind: inlineChar, start: i, end: i + 2, text: rest[1:2]})
+			i += 2
+
+			continue
+		}
+
+		// Code spans
+		if rest[0] == '`' {
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+
+			if end := strings.Index(rest[ticks:], rest[:ticks]); end >= 0 {
+				nodes = append(nodes, &inlineNode{
+					kind: inlineCode, start: i, end: i + 2*ticks + end,
+					text: rest[ticks : ticks+end], textOffset: i + ticks,
+				})
+				i += 2*ticks + end
+
+				continue
+			}
+
+			for j := 0; j < ticks; j++ {
+				nodes = append(nodes, &inlineNode{kind: inlineChar, start: i + j, end: i + j + 1, text: "`"})
+			}
+
+			i += ticks
+
+			continue
+		}
+
+		// Emphasis and strikethrough delimiters
+		if strings.IndexByte("*_~", rest[0]) >= 0 {
+			count := len(rest) - len(strings.TrimLeft(rest, rest[:1]))
+			nodes = append(nodes, delimiterRun(text, i, i+count))
+			i += count
+
+			continue
+		}
+
+		// Links
+		if rest[0] == '[' {
+			if label := strings.Index(rest, "]("); label > 0 {
+				if end := strings.IndexByte(rest[label+2:], ')'); end >= 0 {
+					nodes = append(nodes, &inlineNode{
+						kind: inlineLink, start: i, end: i + label + 3 + end,
+						text: rest[1:label], textOffset: i + 1, url: rest[label+2 : label+2+end],
+					})
+					i += label + 3 + end
+
+					continue
+				}
+			}
+		}
+
+		// Autolinks
+		if match := markdownAutolink.FindStringSubmatch(rest); match != nil {
+			nodes = append(nodes, &inlineNode{
+				kind: inlineAutolink, start: i, end: i + len(match[0]), url: match[1], textOffset: i + 1,
+			})
+			i += len(match[0])
+
+			continue
+		}
+
+		char, _, _, _ := uniseg.FirstGraphemeClusterInString(rest, -1)
+		nodes = append(nodes, &inlineNode{kind: inlineChar, start: i, end: i + len(char), text: char})
+		i += len(char)
+	}
+
+	return nodes
+}
+
+// Returns the node for the run of delimiters from start to end of text,
+// deciding whether it can open or close emphasis by the characters around it.
+func delimiterRun(text string, start, end int) *inlineNode {
+	n := &inlineNode{kind: inlineDelimiters, start: start, end: end, delim: text[start]}
+
+	// Strikethrough takes one or two tildes
+	if n.delim == '~' && end-start > 2 {
+		return n
+	}
+
+	before, after := ' ', ' '
+	if start > 0 {
+		before, _ = utf8.DecodeLastRuneInString(text[:start])
+	}
+
+	if end < len(text) {
+		after, _ = utf8.DecodeRuneInString(text[end:])
+	}
+
+	// A run is left-flanking if it is not followed by whitespace, and not
+	// followed by punctuation unless preceded by whitespace or punctuation,
+	// and right-flanking the other way around.
+	leftFlanking := !unicode.IsSpace(after) &&
+		(!isMarkdownPunct(after) || unicode.IsSpace(before) || isMarkdownPunct(before))
+	rightFlanking := !unicode.IsSpace(before) &&
+		(!isMarkdownPunct(before) || unicode.IsSpace(after) || isMarkdownPunct(after))
+
+	if n.delim == '_' {
+		// Underscores don't emphasize parts of words
+		n.canOpen = leftFlanking && (!rightFlanking || isMarkdownPunct(before))
+		n.canClose = rightFlanking && (!leftFlanking || isMarkdownPunct(after))
+	} else {
+		n.canOpen = leftFlanking
+		n.canClose = rightFlanking
+	}
+
+	return n
+}
+
+// Returns true if r is punctuation or a symbol in the sense of CommonMark.
+func isMarkdownPunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// Matches the delimiter runs that open and close emphasis, as in the
+// CommonMark algorithm, and adds the tags for the emphasis to them. Returns
+// the emphasis found, innermost first.
+func matchEmphasis(nodes []*inlineNode) []emphasis {
+	delims := []*inlineNode{}
+	for _, n := range nodes {
+		if n.kind == inlineDelimiters {
+			delims = append(delims, n)
+		}
+	}
+
+	matches := []emphasis{}
+
+	for c, closer := range delims {
+		for closer.canClose && closer.remaining() > 0 {
+			o := c - 1
+			for ; o >= 0; o-- {
+				if opens(delims[o], closer) {
+					break
+				}
+			}
+
+			if o < 0 {
+				break
+			}
+
+			opener := delims[o]
+
+			used := 1
+			if opener.delim == '~' {
+				used = closer.remaining()
+			} else if opener.remaining() >= 2 && closer.remaining() >= 2 {
+				used = 2
+			}
+
+			tag := "[::i]"
+			switch {
+			case opener.delim == '~':
+				tag = "[::s]"
+			case used == 2:
+				tag = "[::b]"
+			}
+
+			// Emphasis starts after the remaining delimiters of the opener and
+			// ends before those of the closer, innermost emphasis closest to
+			// the text. Emphasis spans are nested, so the closer ends the
+			// innermost ones.
+			start := opener.end - opener.right - used
+			end := closer.start + closer.left + used
+			opener.right += used
+			closer.left += used
+			opener.openTags = append([]string{tag}, opener.openTags...)
+			closer.closes++
+
+			matches = append(matches, emphasis{opener: opener, closer: closer, start: start, end: end})
+
+			// Delimiters inside the emphasis can no longer match
+			for _, d := range delims[o+1 : c] {
+				d.inactive = true
+			}
+		}
+	}
+
+	return matches
+}
+
+// Returns true if the opener can open emphasis closed by the closer.
+func opens(opener, closer *inlineNode) bool {
+	if opener.inactive || !opener.canOpen || opener.remaining() == 0 || opener.delim != closer.delim {
+		return false
+	}
+
+	if opener.delim == '~' {
+		return opener.remaining() == closer.remaining()
+	}
+
+	// The "rule of 3": if either run can both open and close, the sum of
+	// their lengths must not be a multiple of 3, unless both are.
+	a, b := opener.end-opener.start, closer.end-closer.start
+	if (opener.canClose || closer.canOpen) && (a+b)%3 == 0 && (a%3 != 0 || b%3 != 0) {
+		return false
+	}
+
+	return true
+}
+
+// SetMarkdown sets the text content of the TextSel widget from Markdown,
+// rendered with tview tags: headings, emphasis, strikethrough, code spans and
+// fenced code blocks, block quotes, lists, thematic breaks and links. The
+// Markdown source is remembered, so that GetSelectedTextAs(FormatMarkdown)
+// returns the source of the selected text, as does GetSelectedText with
+// SetReturnSource. A selection that covers all of an emphasized text, link or
+// heading includes its markup.
+//
+// Example:
+//
+//	textSel.SetMarkdown("# Answer\n\nUse `go test ./...` to run **all** tests.")
+func (ts *TextSel) SetMarkdown(source string) *TextSel {
+	renderer, text := renderMarkdown(source)
+	ts.SetText(text)
+	ts.source = renderer
+
+	return ts
+}
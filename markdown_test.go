@@ -0,0 +1,126 @@
+package textsel
+
+import (
+	"testing"
+
+	"github.com/rivo/tview"
+)
+
+func TestSetMarkdown(t *testing.T) {
+	source := "# Title\n\nSome **bold** and `[code]`.\n\n- [item](https://x.org)\n\n```go\nx := 1\n```"
+	ts := NewTextSel().SetMarkdown(source)
+
+	expected := "Title\n\nSome bold and [code].\n\n• item\n\n```go\nx := 1\n```"
+	if got := ts.GetText(true); got != expected {
+		t.Errorf("SetMarkdown failed. Expected %q, got %q", expected, got)
+	}
+
+	tests := []struct {
+		start, end [2]int
+		plain      string
+		markdown   string
+	}{
+		{[2]int{0, 0}, [2]int{0, 4}, "Title", "# Title"},
+		{[2]int{2, 5}, [2]int{2, 8}, "bold", "**bold**"},
+		{[2]int{2, 6}, [2]int{2, 7}, "ol", "ol"},
+		{[2]int{2, 14}, [2]int{2, 19}, "[code]", "`[code]`"},
+		{[2]int{4, 0}, [2]int{4, 5}, "• item", "- [item](https://x.org)"},
+		{[2]int{6, 0}, [2]int{7, 5}, "```go\nx := 1", "```go\nx := 1"},
+	}
+
+	for _, test := range tests {
+		ts.ResetSelection()
+		ts.SetCursorPosition(test.start[0], test.start[1]).StartSelection()
+		ts.SetCursorPosition(test.end[0], test.end[1])
+
+		if got := ts.GetSelectedText(); got != test.plain {
+			t.Errorf("GetSelectedText failed. Expected %q, got %q", test.plain, got)
+		}
+
+		if got := ts.GetSelectedTextAs(FormatMarkdown); got != test.markdown {
+			t.Errorf("GetSelectedTextAs(FormatMarkdown) failed. Expected %q, got %q", test.markdown, got)
+		}
+
+		ts.SetReturnSource(true)
+		if got := ts.GetSelectedText(); got != test.markdown {
+			t.Errorf("GetSelectedText with SetReturnSource failed. Expected %q, got %q", test.markdown, got)
+		}
+		ts.SetReturnSource(false)
+	}
+}
+
+func TestMarkdownStyles(t *testing.T) {
+	ts := NewTextSel().SetMarkdown("*it* ~~gone~~ \\*lit\\* <https://x.org>\n> quote\n---")
+
+	expected := "it gone *lit* https://x.org\n│ quote\n───"
+	if got := ts.GetText(true); got != expected {
+		t.Errorf("SetMarkdown failed. Expected %q, got %q", expected, got)
+	}
+
+	expected = "[::i]it[-:-:-:-] [::s]gone[-:-:-:-] *lit* [::u:https://x.org]https://x.org[-:-:-:-]\n[::d]│ [-:-:-:-]quote\n[::d]───[-:-:-:-]"
+	if got := ts.GetText(false); got != expected {
+		t.Errorf("SetMarkdown failed. Expected %q, got %q", expected, got)
+	}
+
+	// Underscores inside words are not emphasis, and emphasis nests
+	tests := []struct {
+		markdown string
+		expected string
+	}{
+		{"call foo_bar_baz now", "call foo_bar_baz now"},
+		{"snake_case_*x*", "snake_case_[::i]x[-:-:-:-]"},
+		{"*a **b** c*", "[::i]a [::b]b[-:-:-:-][::i] c[-:-:-:-]"},
+		{"***x***", "[::i][::b]x[-:-:-:-][::i][-:-:-:-]"},
+		{"_a_ and __b__", "[::i]a[-:-:-:-] and [::b]b[-:-:-:-]"},
+		{"a * b * c", "a * b * c"},
+		{"**a*", "*[::i]a[-:-:-:-]"},
+	}
+
+	for _, test := range tests {
+		if got := ts.SetMarkdown(test.markdown).GetText(false); got != test.expected {
+			t.Errorf("SetMarkdown(%q) failed. Expected %q, got %q", test.markdown, test.expected, got)
+		}
+	}
+
+	// Closing a span restores the style enclosing it
+	code := tview.Styles.SecondaryTextColor.String()
+	tests = []struct {
+		markdown string
+		expected string
+	}{
+		{"## a **b** c", "[::b]a [::b]b[-:-:-:-][::b] c[-:-:-:-]"},
+		{"# a *b* c", "[::bu]a [::i]b[-:-:-:-][::bu] c[-:-:-:-]"},
+		{"# [l](https://x.org) c", "[::bu][::u:https://x.org]l[-:-:-:-][::bu] c[-:-:-:-]"},
+		{"## <https://x.org> c", "[::b][::u:https://x.org]https://x.org[-:-:-:-][::b] c[-:-:-:-]"},
+		{"[`c` d](https://x.org)", "[::u:https://x.org][" + code + "]c[-:-:-:https://x.org][::u] d[-:-:-:-]"},
+		{"**a `c` b**", "[::b]a [" + code + "]c[-:-:-:-][::b] b[-:-:-:-]"},
+	}
+
+	for _, test := range tests {
+		if got := ts.SetMarkdown(test.markdown).GetText(false); got != test.expected {
+			t.Errorf("SetMarkdown(%q) failed. Expected %q, got %q", test.markdown, test.expected, got)
+		}
+	}
+
+	// The source of nested emphasis includes the delimiters of each level
+	ts.SetMarkdown("*a **b** c*").SetCursorPosition(0, 2).StartSelection()
+
+	if got := ts.GetSelectedTextAs(FormatMarkdown); got != "**b**" {
+		t.Errorf("GetSelectedTextAs(FormatMarkdown) failed. Expected %q, got %q", "**b**", got)
+	}
+
+	ts.ResetSelection()
+	ts.SetCursorPosition(0, 0).StartSelection().MoveToEndOfLine()
+
+	if got := ts.GetSelectedTextAs(FormatMarkdown); got != "*a **b** c*" {
+		t.Errorf("GetSelectedTextAs(FormatMarkdown) failed. Expected %q, got %q", "*a **b** c*", got)
+	}
+
+	// Text set without Markdown has no source
+	ts.SetText("**plain**").SetReturnSource(true)
+	ts.StartSelection().MoveToEndOfLine()
+
+	if got := ts.GetSelectedText(); got != "**plain**" {
+		t.Errorf("GetSelectedText failed. Expected %q, got %q", "**plain**", got)
+	}
+}
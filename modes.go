@@ -0,0 +1,221 @@
+package textsel
+
+// Mode is the input mode of a TextSel. The mode determines how keys are
+// interpreted (see Keymap.BindMode) and, in the visual modes, the shape of the
+// selection.
+type Mode int
+
+const (
+	// AnyMode is used for key bindings that apply in every mode. A TextSel is
+	// never in this mode.
+	AnyMode Mode = iota - 1
+
+	// ModeNormal is the mode in which the cursor moves without selecting.
+	ModeNormal
+
+	// ModeVisualChar selects the characters between the selection start and
+	// the cursor.
+	ModeVisualChar
+
+	// ModeVisualLine selects the whole lines between the selection start and
+	// the cursor.
+	ModeVisualLine
+
+	// ModeVisualBlock selects the rectangle spanned by the selection start and
+	// the cursor.
+	ModeVisualBlock
+
+	// ModeSearch is the mode in which a search query is typed.
+	ModeSearch
+
+	// ModePendingOperator is the mode in which an operator (such as yank) is
+	// waiting for the motion it applies to.
+	ModePendingOperator
+)
+
+// Human-readable names of the modes.
+var modeNames = map[Mode]string{
+	AnyMode:             "any",
+	ModeNormal:          "normal",
+	ModeVisualChar:      "visual",
+	ModeVisualLine:      "visual-line",
+	ModeVisualBlock:     "visual-block",
+	ModeSearch:          "search",
+	ModePendingOperator: "operator-pending",
+}
+
+// String returns the name of the mode, e.g. "normal" or "visual-line".
+func (m Mode) String() string {
+	if name, ok := modeNames[m]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+// Returns true if the mode is one of the visual (selecting) modes.
+func (m Mode) isVisual() bool {
+	return m == ModeVisualChar || m == ModeVisualLine || m == ModeVisualBlock
+}
+
+// GetMode returns the current mode.
+func (ts *TextSel) GetMode() Mode {
+	return ts.mode
+}
+
+// SetModeChangedFunc sets the callback function that will be called whenever
+// the mode changes, e.g. to update a mode indicator.
+//
+// Example:
+//
+//	textSel.SetModeChangedFunc(func(from, to textsel.Mode) {
+//		statusBar.SetText("-- " + strings.ToUpper(to.String()) + " --")
+//	})
+func (ts *TextSel) SetModeChangedFunc(f func(from, to Mode)) *TextSel {
+	ts.modeFunc = f
+	return ts
+}
+
+// Changes the mode and notifies the modeFunc callback.
+func (ts *TextSel) setMode(mode Mode) {
+	if mode == ts.mode {
+		return
+	}
+
+	from := ts.mode
+	ts.mode = mode
+
+	if ts.modeFunc != nil {
+		ts.modeFunc(from, mode)
+	}
+}
+
+// Enters a transient mode (search or pending operator), remembering the mode
+// to return to afterwards.
+func (ts *TextSel) enterTransientMode(mode Mode) {
+	if ts.mode != ModeSearch && ts.mode != ModePendingOperator {
+		ts.returnMode = ts.mode
+	}
+
+	ts.setMode(mode)
+}
+
+// Leaves a transient mode, returning to the mode it was entered from.
+func (ts *TextSel) leaveTransientMode() {
+	ts.setMode(ts.returnMode)
+	ts.returnMode = ModeNormal
+}
+
+// Returns true if there is an active selection. This is the case in the
+// visual modes, and in a transient mode entered from a visual mode.
+func (ts *TextSel) isSelecting() bool {
+	if ts.mode == ModeSearch || ts.mode == ModePendingOperator {
+		return ts.returnMode.isVisual()
+	}
+
+	return ts.mode.isVisual()
+}
+
+// Returns the visual mode that determines the shape of the selection.
+func (ts *TextSel) selectionMode() Mode {
+	if ts.mode == ModeSearch || ts.mode == ModePendingOperator {
+		return ts.returnMode
+	}
+
+	return ts.mode
+}
+
+// Cancel leaves the current mode: it cancels a search or pending operator, or
+// the selection in the visual modes.
+func (ts *TextSel) Cancel() *TextSel {
+	switch ts.mode {
+	case ModeSearch:
+		ts.cancelSearch()
+	case ModePendingOperator:
+		ts.cancelOperator()
+	default:
+		ts.ResetSelection()
+	}
+
+	return ts
+}
+
+// Yank waits for a motion and then yanks the text between the cursor and the
+// end of the motion, like vim's `y` operator. The text is handled the same way
+// as a finished selection.
+func (ts *TextSel) Yank() *TextSel {
+	if ts.isSelecting() {
+		return ts.FinishSelection()
+	}
+
+	ts.operatorRow = ts.cursorRow
+	ts.operatorCol = ts.cursorCol
+	ts.operatorCount = 1
+	ts.enterTransientMode(ModePendingOperator)
+
+	return ts
+}
+
+// YankLine yanks the current line, including its trailing newline.
+func (ts *TextSel) YankLine() *TextSel {
+	row, col := ts.cursorRow, ts.cursorCol
+
+	ts.yankSpan(ModeVisualLine, row, col, row, col)
+
+	return ts.SetCursorPosition(row, col)
+}
+
+// Applies the pending operator to the text between the position where the
+// operator was started and the cursor. For exclusive motions, the later of the
+// two positions is not included; for linewise motions, the operator applies
+// to all lines between them.
+func (ts *TextSel) applyOperator(motion Motion) {
+	startRow, startCol := ts.operatorRow, ts.operatorCol
+	endRow, endCol := ts.cursorRow, ts.cursorCol
+
+	if isBefore(endRow, endCol, startRow, startCol) {
+		startRow, startCol, endRow, endCol = endRow, endCol, startRow, startCol
+	}
+
+	if motion == MotionExclusive && (startRow != endRow || startCol != endCol) {
+		s := &textScanner{lines: ts.getGraphemeLines(), row: endRow, col: endCol}
+		s.prev()
+
+		endRow, endCol = s.row, s.col
+	}
+
+	mode := ModeVisualChar
+	if motion == MotionLinewise {
+		mode = ModeVisualLine
+	}
+
+	ts.yankSpan(mode, startRow, startCol, endRow, endCol)
+	ts.SetCursorPosition(startRow, startCol)
+}
+
+// Yanks the text between two positions as if it was selected in the given
+// visual mode, and returns to ModeNormal. The selection is never shown, so
+// the mode-change callback only sees the return to ModeNormal, e.g. from
+// ModePendingOperator.
+func (ts *TextSel) yankSpan(mode Mode, startRow, startCol, endRow, endCol int) {
+	from := ts.mode
+
+	ts.mode = mode
+	ts.returnMode = ModeNormal
+
+	ts.selectionStartRow = startRow
+	ts.selectionStartCol = startCol
+	ts.selectionEndRow = endRow
+	ts.selectionEndCol = endCol
+
+	ts.deliverSelection()
+
+	ts.mode = from
+	ts.ResetSelection()
+}
+
+// Cancels a pending operator.
+func (ts *TextSel) cancelOperator() {
+	ts.leaveTransientMode()
+	ts.highlightCursor()
+}
@@ -0,0 +1,224 @@
+package textsel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func TestModeChanges(t *testing.T) {
+	var changes []string
+
+	ts := NewTextSel().SetKeymap(VimKeymap()).
+		SetText("Hello\nWorld").
+		SetModeChangedFunc(func(from, to Mode) {
+			changes = append(changes, from.String()+">"+to.String())
+		})
+
+	if ts.GetMode() != ModeNormal {
+		t.Errorf("Initial mode failed. Expected normal, got %s", ts.GetMode())
+	}
+
+	ts.handleKeyEvents(runeKey('v'))
+	ts.handleKeyEvents(runeKey('V'))
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyCtrlV, 0, tcell.ModCtrl))
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone))
+
+	expected := []string{"normal>visual", "visual>visual-line", "visual-line>visual-block", "visual-block>normal"}
+
+	if len(changes) != len(expected) {
+		t.Fatalf("Mode changes failed. Expected %v, got %v", expected, changes)
+	}
+
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("Mode changes failed. Expected %v, got %v", expected, changes)
+			break
+		}
+	}
+}
+
+func TestLineSelection(t *testing.T) {
+	ts := NewTextSel().SetText("Hello\nWorld\nFoo")
+
+	ts.SetCursorPosition(0, 2).StartLineSelection().MoveDown()
+
+	if got := ts.GetSelectedText(); got != "Hello\nWorld\n" {
+		t.Errorf("Line selection failed. Expected 'Hello\\nWorld\\n', got: '%s'", got)
+	}
+
+	// Switching the shape keeps the start of the selection
+	ts.StartSelection()
+
+	if got := ts.GetSelectedText(); got != "llo\nWor" {
+		t.Errorf("Switching to char selection failed. Expected 'llo\\nWor', got: '%s'", got)
+	}
+}
+
+func TestBlockSelection(t *testing.T) {
+	ts := NewTextSel().SetText("abcd\nefgh\nij\nklmn")
+
+	ts.SetCursorPosition(0, 1).StartBlockSelection().MoveDown().MoveDown().MoveDown().MoveRight()
+
+	if got := ts.GetSelectedText(); got != "bc\nfg\nj\nlm" {
+		t.Errorf("Block selection failed. Expected 'bc\\nfg\\nj\\nlm', got: '%s'", got)
+	}
+}
+
+func TestBlockSelectionHighlight(t *testing.T) {
+	ts := NewTextSel()
+
+	app := tview.NewApplication()
+	app.SetRoot(ts, true)
+	app.SetFocus(ts)
+
+	ts.SetText("abc\ndef").SetCursorPosition(0, 1).StartBlockSelection().MoveDown()
+
+	expected := "a[black:yellow:-:-]b[white:black:-:-]c \nd[black:yellow:-:-][black:yellow:bu]e[white:black:-:-][white:black:-:-]f"
+	if got := ts.TextView.GetText(false); got != expected {
+		t.Errorf("Block selection highlight failed.\n\nExpected: '%s'\n\n  Actual: '%s'\n\n", visualizeString(expected), visualizeString(got))
+	}
+}
+
+func TestYankModeChanges(t *testing.T) {
+	var changes []string
+
+	ts := NewTextSel().SetKeymap(VimKeymap()).
+		SetText("foo bar\nbaz").
+		SetModeChangedFunc(func(from, to Mode) {
+			changes = append(changes, from.String()+">"+to.String())
+		})
+
+	// The host sees the operator start and end, but not the selection used to
+	// apply it
+	for _, r := range "ywyy" {
+		ts.handleKeyEvents(runeKey(r))
+	}
+
+	expected := []string{
+		"normal>operator-pending", "operator-pending>normal",
+		"normal>operator-pending", "operator-pending>normal",
+	}
+
+	if strings.Join(changes, " ") != strings.Join(expected, " ") {
+		t.Errorf("Yank mode changes failed. Expected %v, got %v", expected, changes)
+	}
+
+	// Yanking a line directly doesn't change the mode at all
+	changes = nil
+	ts.YankLine()
+
+	if len(changes) != 0 {
+		t.Errorf("YankLine changed the mode. Expected no changes, got %v", changes)
+	}
+}
+
+func TestYankOperator(t *testing.T) {
+	var selected string
+
+	ts := NewTextSel().SetKeymap(VimKeymap()).
+		SetText("foo bar baz\nqux").
+		SetSelectFunc(func(text string) { selected = text })
+
+	keys := func(runes string) {
+		for _, r := range runes {
+			ts.handleKeyEvents(runeKey(r))
+		}
+	}
+
+	keys("y")
+
+	if ts.GetMode() != ModePendingOperator {
+		t.Errorf("Yank did not enter pending operator mode. Got %s", ts.GetMode())
+	}
+
+	keys("l")
+
+	if selected != "f" || ts.GetMode() != ModeNormal {
+		t.Errorf("Yank with exclusive motion failed. Expected 'f' in normal mode, got '%s' in %s", selected, ts.GetMode())
+	}
+
+	keys("ye")
+
+	if selected != "foo" {
+		t.Errorf("Yank with inclusive motion failed. Expected 'foo', got '%s'", selected)
+	}
+
+	if row, col := ts.GetCursorPosition(); row != 0 || col != 0 {
+		t.Errorf("Yank did not return the cursor to the start. Expected (0, 0), got (%d, %d)", row, col)
+	}
+
+	keys("jyy")
+
+	if selected != "qux" {
+		t.Errorf("Yanking the line failed. Expected 'qux', got '%s'", selected)
+	}
+
+	if text, _ := ts.GetRegister('0'); text != "qux" {
+		t.Errorf("Yank did not fill the registers. Expected 'qux', got '%s'", text)
+	}
+
+	selected = ""
+	keys("yx")
+
+	if selected != "" || ts.GetMode() != ModeNormal {
+		t.Errorf("Unbound key did not cancel the operator. Got '%s' in %s", selected, ts.GetMode())
+	}
+
+	// In visual mode, y yanks the selection
+	keys("kvly")
+
+	if selected != "fo" || ts.GetMode() != ModeNormal {
+		t.Errorf("Yank in visual mode failed. Expected 'fo' in normal mode, got '%s' in %s", selected, ts.GetMode())
+	}
+
+	// Vertical motions yank whole lines
+	keys("llyj")
+
+	if selected != "foo bar baz\nqux" {
+		t.Errorf("Yank with linewise motion failed. Expected 'foo bar baz\\nqux', got '%s'", selected)
+	}
+
+	keys("jyk")
+
+	if selected != "foo bar baz\nqux" {
+		t.Errorf("Yank with linewise motion failed. Expected 'foo bar baz\\nqux', got '%s'", selected)
+	}
+}
+
+func TestModeSpecificBindings(t *testing.T) {
+	keymap := NewKeymap().
+		Bind("x", ActionMoveRight).
+		BindMode(ModeVisualChar, "x", ActionMoveDown)
+
+	if action, _ := keymap.LookupMode(ModeNormal, "x"); action != ActionMoveRight {
+		t.Errorf("LookupMode failed to fall back to any mode. Got '%s'", action)
+	}
+
+	if action, _ := keymap.LookupMode(ModeVisualChar, "x"); action != ActionMoveDown {
+		t.Errorf("LookupMode failed to prefer the mode's binding. Got '%s'", action)
+	}
+
+	if _, ok := keymap.LookupMode(ModeSearch, "x"); ok {
+		t.Error("LookupMode fell back to any mode in search mode")
+	}
+
+	keymap.UnbindMode(ModeVisualChar, "x")
+
+	if action, _ := keymap.LookupMode(ModeVisualChar, "x"); action != ActionMoveRight {
+		t.Errorf("UnbindMode failed. Got '%s'", action)
+	}
+}
+
+func TestBlockSelectionWideCharacters(t *testing.T) {
+	ts := NewTextSel().SetText("abcdef\n日本語\nabcdef")
+
+	// Cells 1 to 3 cover the whole of "日" and "本"
+	ts.SetCursorPosition(0, 1).StartBlockSelection().MoveDown().MoveDown().MoveRight().MoveRight()
+
+	if got := ts.GetSelectedText(); got != "bcd\n日本\nbcd" {
+		t.Errorf("Block selection with wide characters failed. Expected 'bcd\\n日本\\nbcd', got: '%s'", got)
+	}
+}
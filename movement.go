@@ -10,8 +10,9 @@ func (ts *TextSel) ResetCursor() *TextSel {
 func (ts *TextSel) SetCursorPosition(row int, col int) *TextSel {
 	ts.cursorRow = row
 	ts.cursorCol = col
+	ts.verticalRow = -1
 
-	if ts.isSelecting {
+	if ts.isSelecting() {
 		ts.selectionEndRow = ts.cursorRow
 		ts.selectionEndCol = ts.cursorCol
 	}
@@ -30,17 +31,9 @@ func (ts *TextSel) GetCursorPosition() (int, int) {
 func (ts *TextSel) MoveUp() *TextSel {
 	if ts.cursorRow > 0 {
 		ts.cursorRow--
+		ts.cursorCol = ts.alignedColumn(ts.cursorRow+1, ts.cursorCol)
 
-		currentLine := ts.getCurrentLine()
-
-		if ts.cursorCol > len(currentLine) {
-			ts.cursorCol = len(currentLine) - 1
-			if ts.cursorCol < 0 {
-				ts.cursorCol = 0
-			}
-		}
-
-		if ts.isSelecting {
+		if ts.isSelecting() {
 			ts.selectionEndRow = ts.cursorRow
 			ts.selectionEndCol = ts.cursorCol
 		}
@@ -55,18 +48,9 @@ func (ts *TextSel) MoveUp() *TextSel {
 func (ts *TextSel) MoveDown() *TextSel {
 	if ts.cursorRow < ts.lastRow() {
 		ts.cursorRow++
+		ts.cursorCol = ts.alignedColumn(ts.cursorRow-1, ts.cursorCol)
 
-		currentLine := ts.getCurrentLine()
-
-		if ts.cursorCol > len(currentLine) {
-			ts.cursorCol = len(currentLine) - 1
-
-			if ts.cursorCol < 0 {
-				ts.cursorCol = 0
-			}
-		}
-
-		if ts.isSelecting {
+		if ts.isSelecting() {
 			ts.selectionEndRow = ts.cursorRow
 			ts.selectionEndCol = ts.cursorCol
 		}
@@ -78,15 +62,23 @@ func (ts *TextSel) MoveDown() *TextSel {
 }
 
 // Moves the cursor left by one column, wrapping to the previous row if necessary.
+// With bidi support enabled (see SetBidi), the cursor moves visually.
 func (ts *TextSel) MoveLeft() *TextSel {
-	if ts.cursorCol > 0 {
+	order := ts.visualOrder(graphemes(ts.getCurrentLine()))
+
+	if order != nil && visualPosition(order, ts.cursorCol) > 0 {
+		ts.cursorCol = order[visualPosition(order, ts.cursorCol)-1]
+	} else if order == nil && ts.cursorCol > 0 {
 		ts.cursorCol--
 	} else if ts.cursorRow > 0 {
 		ts.cursorRow--
-		ts.cursorCol = len(ts.getCurrentLine()) - 1 // Adjust to the last valid column in the previous row
+		ts.cursorCol = lineLength(ts.getCurrentLine()) - 1 // Adjust to the last valid column in the previous row
 	}
 
-	if ts.isSelecting {
+	// Horizontal moves forget the column remembered by vertical moves
+	ts.verticalRow = -1
+
+	if ts.isSelecting() {
 		ts.selectionEndRow = ts.cursorRow
 		ts.selectionEndCol = ts.cursorCol
 	}
@@ -97,15 +89,27 @@ func (ts *TextSel) MoveLeft() *TextSel {
 }
 
 // Moves the cursor right by one column, wrapping to the next row if necessary.
+// With bidi support enabled (see SetBidi), the cursor moves visually.
 func (ts *TextSel) MoveRight() *TextSel {
-	if ts.cursorCol < len(ts.getCurrentLine())-1 {
+	order := ts.visualOrder(graphemes(ts.getCurrentLine()))
+
+	if order != nil && visualPosition(order, ts.cursorCol) < len(order)-1 {
+		ts.cursorCol = order[visualPosition(order, ts.cursorCol)+1]
+	} else if order == nil && ts.cursorCol < lineLength(ts.getCurrentLine())-1 {
 		ts.cursorCol++
 	} else if ts.cursorRow < ts.lastRow() {
 		ts.cursorRow++
 		ts.cursorCol = 0
+
+		// Start at the visually leftmost column of the next row
+		if next := ts.visualOrder(graphemes(ts.getCurrentLine())); next != nil {
+			ts.cursorCol = next[0]
+		}
 	}
 
-	if ts.isSelecting {
+	ts.verticalRow = -1
+
+	if ts.isSelecting() {
 		ts.selectionEndRow = ts.cursorRow
 		ts.selectionEndCol = ts.cursorCol
 	}
@@ -119,7 +123,9 @@ func (ts *TextSel) MoveRight() *TextSel {
 func (ts *TextSel) MoveToStartOfLine() *TextSel {
 	ts.cursorCol = 0
 
-	if ts.isSelecting {
+	ts.verticalRow = -1
+
+	if ts.isSelecting() {
 		ts.selectionEndCol = ts.cursorCol
 	}
 
@@ -130,9 +136,11 @@ func (ts *TextSel) MoveToStartOfLine() *TextSel {
 
 // Moves the cursor to the end of the current line.
 func (ts *TextSel) MoveToEndOfLine() *TextSel {
-	ts.cursorCol = len(ts.getCurrentLine()) - 1
+	ts.cursorCol = lineLength(ts.getCurrentLine()) - 1
+
+	ts.verticalRow = -1
 
-	if ts.isSelecting {
+	if ts.isSelecting() {
 		ts.selectionEndCol = ts.cursorCol
 	}
 
@@ -141,18 +149,18 @@ func (ts *TextSel) MoveToEndOfLine() *TextSel {
 	return ts
 }
 
-// Moves the cursor to the first line of the text. The current column is
-// preserved if it is within the bounds of the first line. Otherwise, the
-// cursor is placed at the end of the first line.
+// Moves the cursor to the first line of the text, in the screen column the
+// cursor is displayed in, or the one remembered from the preceding vertical
+// moves (see alignedColumn). If the first line is shorter, the cursor is
+// placed at its end.
 func (ts *TextSel) MoveToFirstLine() *TextSel {
+	fromRow := ts.cursorRow
 	ts.cursorRow = 0
+	ts.cursorCol = ts.alignedColumn(fromRow, ts.cursorCol)
 
-	if ts.isSelecting {
+	if ts.isSelecting() {
 		ts.selectionEndRow = ts.cursorRow
-	}
-
-	if ts.cursorCol > len(ts.getCurrentLine()) {
-		ts.MoveToEndOfLine()
+		ts.selectionEndCol = ts.cursorCol
 	}
 
 	ts.highlightCursor()
@@ -160,21 +168,92 @@ func (ts *TextSel) MoveToFirstLine() *TextSel {
 	return ts
 }
 
-// Moves the cursor to the last line of the text. The current column is
-// preserved if it is within the bounds of the last line. Otherwise, the
-// cursor is placed at the end of the last line.
+// Moves the cursor to the last line of the text, in the screen column the
+// cursor is displayed in, or the one remembered from the preceding vertical
+// moves (see alignedColumn). If the last line is shorter, the cursor is
+// placed at its end.
 func (ts *TextSel) MoveToLastLine() *TextSel {
+	fromRow := ts.cursorRow
 	ts.cursorRow = ts.lastRow()
+	ts.cursorCol = ts.alignedColumn(fromRow, ts.cursorCol)
 
-	if ts.isSelecting {
+	if ts.isSelecting() {
 		ts.selectionEndRow = ts.cursorRow
+		ts.selectionEndCol = ts.cursorCol
 	}
 
-	if ts.cursorCol > len(ts.getCurrentLine()) {
-		ts.MoveToEndOfLine()
+	ts.highlightCursor()
+
+	return ts
+}
+
+// Moves the cursor down by one page, i.e. the height of the widget.
+func (ts *TextSel) MovePageDown() *TextSel {
+	return ts.moveRows(ts.pageSize())
+}
+
+// Moves the cursor up by one page, i.e. the height of the widget.
+func (ts *TextSel) MovePageUp() *TextSel {
+	return ts.moveRows(-ts.pageSize())
+}
+
+// Moves the cursor down by half a page.
+func (ts *TextSel) MoveHalfPageDown() *TextSel {
+	return ts.moveRows(max(ts.pageSize()/2, 1))
+}
+
+// Moves the cursor up by half a page.
+func (ts *TextSel) MoveHalfPageUp() *TextSel {
+	return ts.moveRows(-max(ts.pageSize()/2, 1))
+}
+
+// Returns the number of rows in a page.
+func (ts *TextSel) pageSize() int {
+	_, _, _, height := ts.GetInnerRect()
+	return max(height, 1)
+}
+
+// Moves the cursor by the given number of rows, stopping at the first or last
+// line. The column is adjusted as in MoveUp and MoveDown.
+func (ts *TextSel) moveRows(rows int) *TextSel {
+	fromRow := ts.cursorRow
+	ts.cursorRow = min(max(ts.cursorRow+rows, 0), ts.lastRow())
+	ts.cursorCol = ts.alignedColumn(fromRow, ts.cursorCol)
+
+	if ts.isSelecting() {
+		ts.selectionEndRow = ts.cursorRow
+		ts.selectionEndCol = ts.cursorCol
 	}
 
 	ts.highlightCursor()
 
 	return ts
 }
+
+// Returns the column in the cursor's row that is displayed below or above the
+// given column of the line at fromRow, so that vertical movement keeps the
+// cursor visually aligned when the lines contain wide characters. Like vim,
+// the screen column is remembered across consecutive vertical moves, so that
+// passing through short lines or wide characters does not shift the cursor.
+// Columns beyond the end of the line are moved to its last column.
+func (ts *TextSel) alignedColumn(fromRow int, col int) int {
+	if fromRow == ts.cursorRow {
+		return col
+	}
+
+	cell := ts.verticalCell
+	if fromRow != ts.verticalRow || col != ts.verticalCol {
+		cell = ts.displayColumn(graphemes(ts.getLine(fromRow)), col)
+	}
+
+	line := graphemes(ts.getCurrentLine())
+	col = ts.columnAtDisplay(line, cell)
+
+	if col >= len(line) {
+		col = max(len(line)-1, 0)
+	}
+
+	ts.verticalRow, ts.verticalCol, ts.verticalCell = ts.cursorRow, col, cell
+
+	return col
+}
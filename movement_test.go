@@ -210,3 +210,92 @@ func TestMoveToLastLine(t *testing.T) {
 		t.Errorf("MoveToLastLine failed when last line shorter than current cursor position. Expected cursorRow = 1, cursorCol = 4, got = %d, %d", row, col)
 	}
 }
+
+func TestMovePageDownAndUp(t *testing.T) {
+	ts := NewTextSel().SetText("1\n2\n3\n4\n5\n6\n7\n8\n")
+	ts.SetRect(0, 0, 10, 3)
+
+	ts.MovePageDown()
+	row, _ := ts.GetCursorPosition()
+	if row != 3 {
+		t.Errorf("MovePageDown failed. Expected cursorRow = 3, got = %d", row)
+	}
+
+	ts.MovePageDown().MovePageDown()
+	row, _ = ts.GetCursorPosition()
+	if row != 7 {
+		t.Errorf("MovePageDown failed to stop at EOF. Expected cursorRow = 7, got = %d", row)
+	}
+
+	ts.MoveHalfPageUp()
+	row, _ = ts.GetCursorPosition()
+	if row != 6 {
+		t.Errorf("MoveHalfPageUp failed. Expected cursorRow = 6, got = %d", row)
+	}
+
+	ts.MovePageUp().MovePageUp().MovePageUp()
+	row, _ = ts.GetCursorPosition()
+	if row != 0 {
+		t.Errorf("MovePageUp failed to stop at BOF. Expected cursorRow = 0, got = %d", row)
+	}
+}
+
+func TestMoveVerticallyOverWideCharacters(t *testing.T) {
+	ts := NewTextSel().SetText("日本語テキスト\nabcdefgh\n🇯🇵x")
+
+	// "語" starts at screen cell 4, so moving down lands on "e"
+	ts.SetCursorPosition(0, 2).MoveDown()
+
+	if row, col := ts.GetCursorPosition(); row != 1 || col != 4 {
+		t.Errorf("MoveDown failed to keep the visual column. Expected (1, 4), got (%d, %d)", row, col)
+	}
+
+	// "d" is in the second cell of "本", which is selected as a whole
+	ts.SetCursorPosition(1, 3).MoveUp()
+
+	if row, col := ts.GetCursorPosition(); row != 0 || col != 1 {
+		t.Errorf("MoveUp failed to land on the wide character. Expected (0, 1), got (%d, %d)", row, col)
+	}
+
+	// The flag is a single, double-width grapheme cluster
+	ts.SetCursorPosition(1, 2).MoveDown()
+
+	if row, col := ts.GetCursorPosition(); row != 2 || col != 1 {
+		t.Errorf("MoveDown failed to step over the flag. Expected (2, 1), got (%d, %d)", row, col)
+	}
+}
+
+func TestMoveVerticallyKeepsScreenColumn(t *testing.T) {
+	ts := NewTextSel().SetText("abcdef\n日本語\nab\nabcdef")
+
+	ts.SetCursorPosition(0, 3).MoveDown().MoveDown().MoveDown()
+
+	if row, col := ts.GetCursorPosition(); row != 3 || col != 3 {
+		t.Errorf("Vertical movement did not keep the screen column. Expected (3, 3), got (%d, %d)", row, col)
+	}
+}
+
+func TestMoveVerticallyOntoShorterLastLine(t *testing.T) {
+	ts := NewTextSel().SetText("abc\nab")
+
+	// The last line has no line ending, so its last column is 1
+	ts.SetCursorPosition(0, 2).MoveDown()
+
+	if row, col := ts.GetCursorPosition(); row != 1 || col != 1 {
+		t.Errorf("MoveDown onto a shorter last line failed. Expected (1, 1), got (%d, %d)", row, col)
+	}
+}
+
+func TestHorizontalMoveForgetsScreenColumn(t *testing.T) {
+	ts := NewTextSel().SetText("abcdef\nab\nabcdef")
+
+	// Moving down to the short line remembers screen column 5...
+	ts.SetCursorPosition(0, 5).MoveDown()
+
+	// ...until the cursor moves horizontally, even back to the same column
+	ts.MoveLeft().MoveRight().MoveDown()
+
+	if row, col := ts.GetCursorPosition(); row != 2 || col != 2 {
+		t.Errorf("Horizontal movement did not reset the screen column. Expected (2, 2), got (%d, %d)", row, col)
+	}
+}
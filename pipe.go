@@ -0,0 +1,112 @@
+package textsel
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// PipeResult describes the outcome of piping a selection to an external
+// command with PipeSelection.
+type PipeResult struct {
+	// The command that was run
+	Command []string
+
+	// The text that was written to the command's standard input
+	Input string
+
+	// Everything the command wrote to its standard output and error
+	Stdout string
+	Stderr string
+
+	// The command's exit status, or -1 if it could not be started
+	ExitCode int
+
+	// Set if the command could not be started or exited unsuccessfully
+	Err error
+}
+
+// SetPipeFunc sets the callback function that will be called when a command
+// started by PipeSelection finishes. The callback is called from a separate
+// goroutine; use `Application.QueueUpdateDraw` to update the UI from it.
+//
+// Example:
+//
+//	textSel.SetPipeFunc(func(result textsel.PipeResult) {
+//		app.QueueUpdateDraw(func() {
+//			output.SetText(result.Stdout)
+//		})
+//	})
+func (ts *TextSel) SetPipeFunc(f func(PipeResult)) *TextSel {
+	ts.pipeFunc = f
+	return ts
+}
+
+// SetPipeCommand sets the command run when the `|` key is pressed during a
+// selection. Pass nil to disable the binding.
+//
+// Example:
+//
+//	textSel.SetPipeCommand([]string{"tr", "a-z", "A-Z"})
+func (ts *TextSel) SetPipeCommand(cmd []string) *TextSel {
+	ts.pipeCommand = cmd
+	return ts
+}
+
+// PipeSelection runs cmd with the selected text on its standard input and
+// finishes the selection, like tmux's `copy-pipe`. The command runs in the
+// background so that the event loop is not blocked; its output is delivered
+// to the pipeFunc callback. Nothing happens if no text is being selected.
+func (ts *TextSel) PipeSelection(cmd []string) *TextSel {
+	if !ts.isSelecting() {
+		return ts
+	}
+
+	text := ts.GetSelectedText()
+	done := ts.pipeFunc
+
+	go func() {
+		result := runPipe(cmd, text)
+
+		if done != nil {
+			done(result)
+		}
+	}()
+
+	ts.ResetSelection()
+
+	return ts
+}
+
+// Runs cmd with input on its standard input and collects the result.
+func runPipe(cmd []string, input string) PipeResult {
+	result := PipeResult{
+		Command:  cmd,
+		Input:    input,
+		ExitCode: -1,
+	}
+
+	if len(cmd) == 0 {
+		result.Err = errors.New("pipe: empty command")
+		return result
+	}
+
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+
+	proc := exec.Command(cmd[0], cmd[1:]...)
+	proc.Stdin = strings.NewReader(input)
+	proc.Stdout = &stdout
+	proc.Stderr = &stderr
+
+	result.Err = proc.Run()
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	if proc.ProcessState != nil {
+		result.ExitCode = proc.ProcessState.ExitCode()
+	}
+
+	return result
+}
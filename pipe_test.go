@@ -0,0 +1,78 @@
+package textsel
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestPipeSelection(t *testing.T) {
+	results := make(chan PipeResult, 1)
+
+	ts := NewTextSel().
+		SetText("Hello, World!").
+		SetPipeFunc(func(result PipeResult) { results <- result })
+
+	ts.StartSelection().MoveRight().MoveRight().PipeSelection([]string{"tr", "a-z", "A-Z"})
+
+	if ts.isSelecting() {
+		t.Error("PipeSelection did not finish the selection")
+	}
+
+	result := <-results
+
+	if result.Err != nil {
+		t.Fatalf("PipeSelection failed: %v", result.Err)
+	}
+
+	if result.Input != "Hel" || result.Stdout != "HEL" || result.ExitCode != 0 {
+		t.Errorf("PipeSelection failed. Expected 'Hel' -> 'HEL' (0), got: '%s' -> '%s' (%d)", result.Input, result.Stdout, result.ExitCode)
+	}
+}
+
+func TestPipeSelectionFailure(t *testing.T) {
+	results := make(chan PipeResult, 1)
+
+	ts := NewTextSel().
+		SetText("Hello, World!").
+		SetPipeFunc(func(result PipeResult) { results <- result })
+
+	ts.StartSelection().PipeSelection([]string{"sh", "-c", "echo oops >&2; exit 3"})
+
+	result := <-results
+
+	if result.Err == nil || result.ExitCode != 3 || result.Stderr != "oops\n" {
+		t.Errorf("PipeSelection failure not reported. Got err = %v, exit code = %d, stderr = '%s'", result.Err, result.ExitCode, result.Stderr)
+	}
+
+	ts.StartSelection().PipeSelection(nil)
+
+	result = <-results
+
+	if result.Err == nil || result.ExitCode != -1 {
+		t.Errorf("PipeSelection with empty command not reported. Got err = %v, exit code = %d", result.Err, result.ExitCode)
+	}
+}
+
+func TestPipeCommandBinding(t *testing.T) {
+	results := make(chan PipeResult, 1)
+
+	ts := NewTextSel().SetKeymap(VimKeymap()).
+		SetText("Hello, World!").
+		SetPipeFunc(func(result PipeResult) { results <- result })
+
+	// Without a pipe command, the key does nothing
+	ts.StartSelection()
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyRune, '|', tcell.ModNone))
+
+	if !ts.isSelecting() {
+		t.Error("'|' finished the selection without a pipe command")
+	}
+
+	ts.SetPipeCommand([]string{"cat"})
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyRune, '|', tcell.ModNone))
+
+	if result := <-results; result.Stdout != "H" {
+		t.Errorf("Pipe binding failed. Expected 'H', got: '%s'", result.Stdout)
+	}
+}
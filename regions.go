@@ -0,0 +1,197 @@
+package textsel
+
+// Region is a span of text marked with tview region tags, e.g. the text
+// "world" in `hello ["greeting"]world[""]`. Positions are the same as those
+// of the cursor; the end is the position of the last character of the span.
+// A region ID may be used for several spans.
+type Region struct {
+	ID       string
+	StartRow int
+	StartCol int
+	EndRow   int
+	EndCol   int
+}
+
+// Returns true if the given position lies within the region.
+func (r Region) contains(row, col int) bool {
+	return !isBefore(row, col, r.StartRow, r.StartCol) && !isBefore(r.EndRow, r.EndCol, row, col)
+}
+
+// Returns true if position (row1, col1) is before position (row2, col2).
+func isBefore(row1, col1, row2, col2 int) bool {
+	return row1 < row2 || (row1 == row2 && col1 < col2)
+}
+
+// GetRegions returns the spans of text marked with region tags, in the order
+// in which they appear. Empty spans are omitted.
+//
+// Example:
+//
+//	for _, region := range textSel.GetRegions() {
+//		fmt.Println(region.ID, region.StartRow, region.StartCol)
+//	}
+func (ts *TextSel) GetRegions() []Region {
+	regions := []Region{}
+	current := Region{}
+	row, col := 0, 0
+	empty := true
+
+	for _, token := range splitTags(ts.text) {
+		if token.isRegionTag() {
+			if !empty && current.ID != "" {
+				regions = append(regions, current)
+			}
+
+			current = Region{ID: token.raw[2 : len(token.raw)-2], StartRow: row, StartCol: col}
+			empty = true
+
+			continue
+		}
+
+		if token.isTag() {
+			continue
+		}
+
+		current.EndRow, current.EndCol = row, col
+		empty = false
+
+		if isLineEnding(token.char) {
+			row++
+			col = 0
+		} else {
+			col++
+		}
+	}
+
+	if !empty && current.ID != "" {
+		regions = append(regions, current)
+	}
+
+	return regions
+}
+
+// RegionAtCursor returns the ID of the region the cursor is in, or an empty
+// string if it is not in a region.
+//
+// Example:
+//
+//	if id := textSel.RegionAtCursor(); id != "" {
+//		showDetails(id)
+//	}
+func (ts *TextSel) RegionAtCursor() string {
+	if region, ok := ts.regionAt(ts.cursorRow, ts.cursorCol); ok {
+		return region.ID
+	}
+
+	return ""
+}
+
+// Returns the region span at the given position.
+func (ts *TextSel) regionAt(row, col int) (Region, bool) {
+	for _, region := range ts.GetRegions() {
+		if region.contains(row, col) {
+			return region, true
+		}
+	}
+
+	return Region{}, false
+}
+
+// NextRegion moves the cursor to the start of the next region span after the
+// cursor. If there is none, the cursor does not move.
+//
+// Example:
+//
+//	textSel.NextRegion()
+func (ts *TextSel) NextRegion() *TextSel {
+	for _, region := range ts.GetRegions() {
+		if isBefore(ts.cursorRow, ts.cursorCol, region.StartRow, region.StartCol) {
+			return ts.SetCursorPosition(region.StartRow, region.StartCol)
+		}
+	}
+
+	return ts
+}
+
+// PrevRegion moves the cursor to the start of the previous region span before
+// the cursor. If the cursor is inside a span, it moves to the start of that
+// span first. If there is none, the cursor does not move.
+//
+// Example:
+//
+//	textSel.PrevRegion()
+func (ts *TextSel) PrevRegion() *TextSel {
+	regions := ts.GetRegions()
+
+	for i := len(regions) - 1; i >= 0; i-- {
+		if isBefore(regions[i].StartRow, regions[i].StartCol, ts.cursorRow, ts.cursorCol) {
+			return ts.SetCursorPosition(regions[i].StartRow, regions[i].StartCol)
+		}
+	}
+
+	return ts
+}
+
+// SelectRegion selects the first span of the region with the given ID and
+// moves the cursor to its end. Returns false if there is no such region.
+//
+// Example:
+//
+//	if textSel.SelectRegion("message-3") {
+//		textSel.FinishSelection()
+//	}
+func (ts *TextSel) SelectRegion(id string) bool {
+	for _, region := range ts.GetRegions() {
+		if region.ID == id {
+			ts.selectSpan(region.StartRow, region.StartCol, region.EndRow, region.EndCol)
+			return true
+		}
+	}
+
+	return false
+}
+
+// SelectRegionAtCursor selects the region span the cursor is in, and moves
+// the cursor to its end. In ModePendingOperator, the operator applies to the
+// span. If the cursor is not in a region, nothing happens.
+//
+// Example:
+//
+//	textSel.SelectRegionAtCursor().FinishSelection()
+func (ts *TextSel) SelectRegionAtCursor() *TextSel {
+	if region, ok := ts.regionAt(ts.cursorRow, ts.cursorCol); ok {
+		ts.selectSpan(region.StartRow, region.StartCol, region.EndRow, region.EndCol)
+	}
+
+	return ts
+}
+
+// Selects the text from the start to the end position, or makes it the
+// target of the pending operator.
+func (ts *TextSel) selectSpan(startRow, startCol, endRow, endCol int) {
+	if ts.mode == ModePendingOperator {
+		ts.operatorRow, ts.operatorCol = startRow, startCol
+	} else {
+		if !ts.isSelecting() {
+			ts.StartSelection()
+		}
+
+		ts.selectionStartRow, ts.selectionStartCol = startRow, startCol
+	}
+
+	ts.SetCursorPosition(endRow, endCol)
+}
+
+// SetHighlightRegionAtCursor sets whether the region the cursor is in is
+// highlighted with `tview.TextView.Highlight`, so that the span under the
+// cursor stands out as it moves. The default is false, which leaves the
+// highlights to the application.
+//
+// Example:
+//
+//	textSel.SetHighlightRegionAtCursor(true)
+func (ts *TextSel) SetHighlightRegionAtCursor(enabled bool) *TextSel {
+	ts.highlightRegion = enabled
+	ts.highlightCursor()
+	return ts
+}
@@ -0,0 +1,139 @@
+package textsel
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rivo/tview"
+)
+
+const regionText = "say [\"a\"][red]hello[-][\"\"] to\n[\"b\"]the\nworld[\"\"] and [\"a\"]bye[\"\"]"
+
+func TestGetRegions(t *testing.T) {
+	ts := NewTextSel().SetText(regionText)
+
+	expected := []Region{
+		{ID: "a", StartRow: 0, StartCol: 4, EndRow: 0, EndCol: 8},
+		{ID: "b", StartRow: 1, StartCol: 0, EndRow: 2, EndCol: 4},
+		{ID: "a", StartRow: 2, StartCol: 10, EndRow: 2, EndCol: 12},
+	}
+
+	if got := ts.GetRegions(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("GetRegions failed. Expected %v, got %v", expected, got)
+	}
+}
+
+func TestRegionAtCursor(t *testing.T) {
+	ts := NewTextSel().SetText(regionText)
+
+	tests := []struct {
+		row, col int
+		expected string
+	}{
+		{0, 3, ""},
+		{0, 4, "a"},
+		{0, 8, "a"},
+		{0, 9, ""},
+		{1, 3, "b"},
+		{2, 0, "b"},
+		{2, 5, ""},
+		{2, 11, "a"},
+	}
+
+	for _, test := range tests {
+		ts.SetCursorPosition(test.row, test.col)
+
+		if got := ts.RegionAtCursor(); got != test.expected {
+			t.Errorf("RegionAtCursor at (%d, %d) failed. Expected '%s', got '%s'", test.row, test.col, test.expected, got)
+		}
+	}
+}
+
+func TestNextAndPrevRegion(t *testing.T) {
+	ts := NewTextSel().SetText(regionText)
+
+	positions := [][2]int{{0, 4}, {1, 0}, {2, 10}, {2, 10}}
+	for _, expected := range positions {
+		ts.NextRegion()
+
+		if row, col := ts.GetCursorPosition(); row != expected[0] || col != expected[1] {
+			t.Errorf("NextRegion failed. Expected (%d, %d), got (%d, %d)", expected[0], expected[1], row, col)
+		}
+	}
+
+	ts.SetCursorPosition(2, 2)
+
+	positions = [][2]int{{1, 0}, {0, 4}, {0, 4}}
+	for _, expected := range positions {
+		ts.PrevRegion()
+
+		if row, col := ts.GetCursorPosition(); row != expected[0] || col != expected[1] {
+			t.Errorf("PrevRegion failed. Expected (%d, %d), got (%d, %d)", expected[0], expected[1], row, col)
+		}
+	}
+}
+
+func TestSelectRegion(t *testing.T) {
+	var selected string
+
+	ts := NewTextSel().SetKeymap(VimKeymap()).
+		SetText(regionText).
+		SetSelectFunc(func(text string) { selected = text })
+
+	if ts.SelectRegion("missing") {
+		t.Errorf("SelectRegion failed. Expected false for an unknown region")
+	}
+
+	if !ts.SelectRegion("b") {
+		t.Errorf("SelectRegion failed. Expected true for region 'b'")
+	}
+
+	if got := ts.GetSelectedText(); got != "the\nworld" {
+		t.Errorf("SelectRegion failed. Expected 'the\\nworld', got '%s'", got)
+	}
+
+	ts.ResetSelection()
+	ts.SetCursorPosition(0, 6)
+
+	for _, r := range "yir" {
+		ts.handleKeyEvents(runeKey(r))
+	}
+
+	if selected != "hello" {
+		t.Errorf("Yanking the region failed. Expected 'hello', got '%s'", selected)
+	}
+
+	for _, r := range "]r]rvir" {
+		ts.handleKeyEvents(runeKey(r))
+	}
+
+	if got := ts.GetSelectedText(); got != "bye" {
+		t.Errorf("Selecting the region with keys failed. Expected 'bye', got '%s'", got)
+	}
+}
+
+func TestHighlightRegionAtCursor(t *testing.T) {
+	ts := NewTextSel().SetText(regionText)
+
+	app := tview.NewApplication()
+	app.SetRoot(ts, true)
+	app.SetFocus(ts)
+
+	ts.SetCursorPosition(1, 1)
+
+	if got := ts.GetHighlights(); len(got) != 0 {
+		t.Errorf("Region highlight failed. Expected no highlights by default, got %v", got)
+	}
+
+	ts.SetHighlightRegionAtCursor(true)
+
+	if got := ts.GetHighlights(); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("Region highlight failed. Expected [b], got %v", got)
+	}
+
+	ts.SetCursorPosition(0, 0)
+
+	if got := ts.GetHighlights(); len(got) != 0 {
+		t.Errorf("Region highlight failed. Expected no highlights outside regions, got %v", got)
+	}
+}
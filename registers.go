@@ -0,0 +1,111 @@
+package textsel
+
+import (
+	"strings"
+	"unicode"
+)
+
+// UnnamedRegister is the name of the default register. It always holds the
+// most recently finished selection.
+const UnnamedRegister = '"'
+
+// The number of numbered registers ("0" through "9") kept in the ring of
+// recent yanks.
+const numberedRegisters = 10
+
+// SetRegisterChangedFunc sets the callback function that will be called
+// whenever the content of a register changes. It receives the register's name
+// and its new content.
+//
+// Example:
+//
+//	textSel.SetRegisterChangedFunc(func(name rune, text string) {
+//		statusBar.SetText(fmt.Sprintf("\"%c: %d bytes", name, len(text)))
+//	})
+func (ts *TextSel) SetRegisterChangedFunc(f func(name rune, text string)) *TextSel {
+	ts.registerFunc = f
+	return ts
+}
+
+// GetRegister returns the content of the named register. Valid names are the
+// unnamed register ('"'), the named registers 'a' through 'z' and the
+// numbered registers '0' through '9', where '0' is the most recent yank and
+// '9' the oldest. Uppercase names refer to the corresponding named register.
+// The second return value is false if the register is invalid or empty.
+func (ts *TextSel) GetRegister(name rune) (string, bool) {
+	name = unicode.ToLower(name)
+
+	if !isValidRegister(name) {
+		return "", false
+	}
+
+	text, ok := ts.registers[name]
+	return text, ok
+}
+
+// SetRegister sets the content of a register directly, e.g. to restore
+// registers persisted by the host. Uppercase names append to the
+// corresponding named register instead of replacing it.
+func (ts *TextSel) SetRegister(name rune, text string) *TextSel {
+	if unicode.IsUpper(name) {
+		name = unicode.ToLower(name)
+		text = ts.registers[name] + text
+	}
+
+	if isValidRegister(name) {
+		ts.setRegister(name, text)
+	}
+
+	return ts
+}
+
+// SelectRegister selects the register that the next finished selection is
+// stored in, in addition to the unnamed and numbered registers. This is the
+// equivalent of vim's `"a` prefix. Uppercase names append to the named
+// register. Invalid names are ignored.
+func (ts *TextSel) SelectRegister(name rune) *TextSel {
+	if isValidRegister(unicode.ToLower(name)) {
+		ts.pendingRegister = name
+	}
+
+	return ts
+}
+
+// Stores text in the registers after a selection has been finished.
+func (ts *TextSel) yank(text string) {
+	// Shift the numbered registers to make room for the new yank at "0"
+	for i := numberedRegisters - 1; i > 0; i-- {
+		older, ok := ts.registers[rune('0'+i-1)]
+		if ok {
+			ts.setRegister(rune('0'+i), older)
+		}
+	}
+
+	ts.setRegister('0', text)
+	ts.setRegister(UnnamedRegister, text)
+
+	if ts.pendingRegister != 0 {
+		ts.SetRegister(ts.pendingRegister, text)
+		ts.pendingRegister = 0
+	}
+}
+
+// Sets the content of a register and notifies the registerFunc callback.
+func (ts *TextSel) setRegister(name rune, text string) {
+	if ts.registers == nil {
+		ts.registers = map[rune]string{}
+	}
+
+	ts.registers[name] = text
+
+	if ts.registerFunc != nil {
+		ts.registerFunc(name, text)
+	}
+}
+
+// Returns true if name is the name of a register (in lowercase).
+func isValidRegister(name rune) bool {
+	return name == UnnamedRegister ||
+		(name >= 'a' && name <= 'z') ||
+		strings.ContainsRune("0123456789", name)
+}
@@ -0,0 +1,90 @@
+package textsel
+
+import (
+	"testing"
+)
+
+func TestUnnamedAndNumberedRegisters(t *testing.T) {
+	ts := NewTextSel().SetText("one two three")
+
+	ts.StartSelection().MoveRight().MoveRight().FinishSelection()
+	ts.SetCursorPosition(0, 4).StartSelection().MoveRight().MoveRight().FinishSelection()
+
+	if got, _ := ts.GetRegister(UnnamedRegister); got != "two" {
+		t.Errorf("Unnamed register failed. Expected 'two', got: '%s'", got)
+	}
+
+	if got, _ := ts.GetRegister('0'); got != "two" {
+		t.Errorf("Register 0 failed. Expected 'two', got: '%s'", got)
+	}
+
+	if got, _ := ts.GetRegister('1'); got != "one" {
+		t.Errorf("Register 1 failed. Expected 'one', got: '%s'", got)
+	}
+
+	if _, ok := ts.GetRegister('2'); ok {
+		t.Error("Register 2 should be empty")
+	}
+}
+
+func TestNumberedRegisterRing(t *testing.T) {
+	ts := NewTextSel().SetText("abcdefghijkl")
+
+	for col := 0; col < 12; col++ {
+		ts.SetCursorPosition(0, col).StartSelection().FinishSelection()
+	}
+
+	if got, _ := ts.GetRegister('0'); got != "l" {
+		t.Errorf("Register 0 failed. Expected 'l', got: '%s'", got)
+	}
+
+	if got, _ := ts.GetRegister('9'); got != "c" {
+		t.Errorf("Register 9 failed. Expected 'c', got: '%s'", got)
+	}
+}
+
+func TestNamedRegisters(t *testing.T) {
+	ts := NewTextSel().SetText("one two")
+
+	ts.SelectRegister('a').StartSelection().MoveRight().MoveRight().FinishSelection()
+
+	if got, _ := ts.GetRegister('a'); got != "one" {
+		t.Errorf("Named register failed. Expected 'one', got: '%s'", got)
+	}
+
+	// The selected register only applies to a single selection
+	ts.SetCursorPosition(0, 4).StartSelection().MoveRight().MoveRight().FinishSelection()
+
+	if got, _ := ts.GetRegister('a'); got != "one" {
+		t.Errorf("Named register was overwritten. Expected 'one', got: '%s'", got)
+	}
+
+	// Uppercase names append
+	ts.SelectRegister('A').SetCursorPosition(0, 3).StartSelection().MoveToEndOfLine().FinishSelection()
+
+	if got, _ := ts.GetRegister('a'); got != "one two" {
+		t.Errorf("Appending to named register failed. Expected 'one two', got: '%s'", got)
+	}
+
+	if _, ok := ts.GetRegister('!'); ok {
+		t.Error("Invalid register name should not be readable")
+	}
+}
+
+func TestRegisterChangedFunc(t *testing.T) {
+	changed := map[rune]string{}
+
+	ts := NewTextSel().
+		SetText("Hello").
+		SetRegisterChangedFunc(func(name rune, text string) {
+			changed[name] = text
+		})
+
+	ts.SelectRegister('x').StartSelection().MoveRight().FinishSelection()
+
+	for _, name := range []rune{UnnamedRegister, '0', 'x'} {
+		if changed[name] != "He" {
+			t.Errorf("Register change for '%c' not reported. Expected 'He', got: '%s'", name, changed[name])
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package textsel
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
+)
+
+// Scrolls the view by the given number of lines without moving the cursor.
+func (ts *TextSel) scrollBy(lines int) {
+	// The offset is -1 until the view has been drawn
+	row, col := ts.GetScrollOffset()
+	row = max(row, 0)
+
+	ts.ScrollTo(max(row+lines, 0), col)
+}
+
+// Scrolls the view just far enough for the cursor to be visible.
+func (ts *TextSel) scrollToCursor() {
+	_, _, width, height := ts.GetInnerRect()
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	line := ts.cursorScreenLine(width)
+	offset, col := ts.GetScrollOffset()
+	offset = max(offset, 0)
+
+	if line < offset {
+		ts.ScrollTo(line, col)
+	} else if line >= offset+height {
+		ts.ScrollTo(line-height+1, col)
+	}
+}
+
+// Returns the index of the screen line the cursor is displayed on when the
+// text is word wrapped at the given width.
+func (ts *TextSel) cursorScreenLine(width int) int {
+	lines := strings.Split(ts.TextView.GetText(false), "\n")
+	screenLine := 0
+
+	for row := 0; row < ts.cursorRow && row < len(lines); row++ {
+		screenLine += max(len(tview.WordWrap(lines[row], width)), 1)
+	}
+
+	if ts.cursorRow >= len(lines) {
+		return screenLine
+	}
+
+	// Find the wrapped part of the cursor's line that contains the cursor,
+	// comparing screen cells because tabs have been expanded in the displayed
+	// text.
+	wrapped := tview.WordWrap(lines[ts.cursorRow], width)
+	cursorCell := ts.displayColumn(graphemes(ts.getCurrentLine()), ts.cursorCol)
+	cell := 0
+
+	for i := 0; i < len(wrapped)-1; i++ {
+		cell += uniseg.StringWidth(stripTags(wrapped[i]))
+
+		if cursorCell < cell {
+			return screenLine + i
+		}
+	}
+
+	return screenLine + max(len(wrapped)-1, 0)
+}
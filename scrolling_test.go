@@ -0,0 +1,53 @@
+package textsel
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestScrollToCursor(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	screen.Init()
+	screen.SetSize(20, 3)
+
+	ts := NewTextSel().SetText("1\n2\n3\n4\n5\n6\n7\n8\n")
+	ts.SetRect(0, 0, 20, 3)
+	ts.Draw(screen)
+
+	ts.MoveDown().MoveDown().MoveDown().MoveDown()
+	ts.Draw(screen)
+
+	if row, _ := ts.GetScrollOffset(); row != 2 {
+		t.Errorf("View did not follow the cursor down. Expected scroll offset 2, got %d", row)
+	}
+
+	ts.MoveUp().MoveUp().MoveUp()
+	ts.Draw(screen)
+
+	if row, _ := ts.GetScrollOffset(); row != 1 {
+		t.Errorf("View did not follow the cursor up. Expected scroll offset 1, got %d", row)
+	}
+
+	// Scrolling without moving the cursor is not undone by the next draw
+	ts.Execute(ActionScrollDown, 3)
+	ts.Draw(screen)
+
+	if row, _ := ts.GetScrollOffset(); row != 4 {
+		t.Errorf("Scrolling the view failed. Expected scroll offset 4, got %d", row)
+	}
+}
+
+func TestCursorScreenLine(t *testing.T) {
+	ts := NewTextSel().SetText("one two three\nfour")
+
+	ts.MoveDown()
+	if line := ts.cursorScreenLine(8); line != 2 {
+		t.Errorf("cursorScreenLine failed with wrapped lines. Expected 2, got %d", line)
+	}
+
+	ts.MoveUp().MoveToEndOfLine()
+	if line := ts.cursorScreenLine(8); line != 1 {
+		t.Errorf("cursorScreenLine failed inside a wrapped line. Expected 1, got %d", line)
+	}
+}
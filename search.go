@@ -0,0 +1,177 @@
+package textsel
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// StartSearch enters ModeSearch, in which typed keys build up a search query.
+// Enter searches forward for the query (or repeats the last search if the
+// query is empty) and returns to the previous mode, Escape cancels the search
+// and Backspace deletes the last character of the query. If a selection is in
+// progress, it is extended to the match.
+func (ts *TextSel) StartSearch() *TextSel {
+	ts.setSearchQuery("")
+	ts.enterTransientMode(ModeSearch)
+	return ts
+}
+
+// SetSearchQueryChangedFunc sets the callback function that will be called
+// whenever the query being typed in ModeSearch changes, e.g. to show it in a
+// status bar. It is called with an empty query when the search is submitted
+// or cancelled.
+//
+// Example:
+//
+//	textSel.SetSearchQueryChangedFunc(func(query string) {
+//		statusBar.SetText("/" + query)
+//	})
+func (ts *TextSel) SetSearchQueryChangedFunc(f func(query string)) *TextSel {
+	ts.searchQueryFunc = f
+	return ts
+}
+
+// Changes the query being typed and notifies the searchQueryFunc callback.
+func (ts *TextSel) setSearchQuery(query string) {
+	if query == ts.searchQuery {
+		return
+	}
+
+	ts.searchQuery = query
+
+	if ts.searchQueryFunc != nil {
+		ts.searchQueryFunc(query)
+	}
+}
+
+// GetSearchQuery returns the query being typed in ModeSearch, or the most
+// recent search query in any other mode.
+func (ts *TextSel) GetSearchQuery() string {
+	if ts.mode == ModeSearch {
+		return ts.searchQuery
+	}
+
+	return ts.lastSearch
+}
+
+// Search moves the cursor forward to the next occurrence of query, wrapping
+// around at the end of the text. Format codes are ignored. Returns false if
+// the query does not occur in the text.
+//
+// Example:
+//
+//	if !textSel.Search("TODO") {
+//		statusBar.SetText("Pattern not found")
+//	}
+func (ts *TextSel) Search(query string) bool {
+	ts.lastSearch = query
+	ts.lastSearchBack = false
+	return ts.searchFrom(query, false)
+}
+
+// SearchBackward is like Search, but moves the cursor backward to the previous
+// occurrence of query.
+func (ts *TextSel) SearchBackward(query string) bool {
+	ts.lastSearch = query
+	ts.lastSearchBack = true
+	return ts.searchFrom(query, true)
+}
+
+// SearchNext repeats the last search in the same direction. In ModeSearch, it
+// submits the query being typed instead.
+func (ts *TextSel) SearchNext() bool {
+	if ts.mode == ModeSearch {
+		return ts.submitSearch()
+	}
+
+	return ts.searchFrom(ts.lastSearch, ts.lastSearchBack)
+}
+
+// SearchPrev repeats the last search in the opposite direction.
+func (ts *TextSel) SearchPrev() bool {
+	return ts.searchFrom(ts.lastSearch, !ts.lastSearchBack)
+}
+
+// Moves the cursor to the next occurrence of query in the given direction.
+func (ts *TextSel) searchFrom(query string, backward bool) bool {
+	if query == "" {
+		return false
+	}
+
+	text := ts.GetText(true)
+	offset := ts.offsetOf(ts.cursorRow, ts.cursorCol)
+	pos := -1
+
+	if backward {
+		pos = strings.LastIndex(text[:min(offset, len(text))], query)
+
+		if pos < 0 {
+			pos = strings.LastIndex(text, query)
+		}
+	} else {
+		next := ts.offsetOf(ts.cursorRow, ts.cursorCol+1)
+
+		if next <= len(text) {
+			if idx := strings.Index(text[next:], query); idx >= 0 {
+				pos = next + idx
+			}
+		}
+
+		if pos < 0 {
+			pos = strings.Index(text, query)
+		}
+	}
+
+	if pos < 0 {
+		return false
+	}
+
+	ts.SetCursorPosition(ts.positionOf(pos))
+
+	return true
+}
+
+// Handles a key typed in ModeSearch that is not bound in the keymap. Returns
+// true if the key was used.
+func (ts *TextSel) handleSearchInput(event *tcell.EventKey) bool {
+	switch event.Key() {
+	case tcell.KeyEnter:
+		ts.submitSearch()
+	case tcell.KeyEsc:
+		ts.cancelSearch()
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(ts.searchQuery) == 0 {
+			ts.cancelSearch()
+		} else {
+			runes := []rune(ts.searchQuery)
+			ts.setSearchQuery(string(runes[:len(runes)-1]))
+		}
+	case tcell.KeyRune:
+		ts.setSearchQuery(ts.searchQuery + string(event.Rune()))
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Leaves ModeSearch and searches for the query that was typed. An empty query
+// repeats the last search.
+func (ts *TextSel) submitSearch() bool {
+	query := ts.searchQuery
+	ts.setSearchQuery("")
+	ts.leaveTransientMode()
+
+	if query == "" {
+		return ts.SearchNext()
+	}
+
+	return ts.Search(query)
+}
+
+// Leaves ModeSearch without searching.
+func (ts *TextSel) cancelSearch() {
+	ts.setSearchQuery("")
+	ts.leaveTransientMode()
+}
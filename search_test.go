@@ -0,0 +1,136 @@
+package textsel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestSearch(t *testing.T) {
+	ts := NewTextSel().SetText("[red]foo[-] bar\nfoo baz foo")
+
+	expected := [][2]int{{1, 0}, {1, 8}, {0, 0}}
+
+	for i, pos := range expected {
+		var found bool
+		if i == 0 {
+			found = ts.Search("foo")
+		} else {
+			found = ts.SearchNext()
+		}
+
+		row, col := ts.GetCursorPosition()
+		if !found || row != pos[0] || col != pos[1] {
+			t.Errorf("Search failed. Expected (%d, %d), got (%d, %d)", pos[0], pos[1], row, col)
+		}
+	}
+
+	ts.SearchPrev()
+
+	if row, col := ts.GetCursorPosition(); row != 1 || col != 8 {
+		t.Errorf("SearchPrev failed to wrap around. Expected (1, 8), got (%d, %d)", row, col)
+	}
+
+	if ts.Search("nope") {
+		t.Error("Search for missing text succeeded")
+	}
+}
+
+func TestSearchMode(t *testing.T) {
+	ts := NewTextSel().SetKeymap(VimKeymap()).SetText("Hello, World!")
+
+	ts.handleKeyEvents(runeKey('/'))
+
+	if ts.GetMode() != ModeSearch {
+		t.Fatalf("'/' did not enter search mode. Got %s", ts.GetMode())
+	}
+
+	// Keys bound in other modes are typed into the query
+	for _, r := range "Wox" {
+		ts.handleKeyEvents(runeKey(r))
+	}
+
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone))
+
+	if got := ts.GetSearchQuery(); got != "Wo" {
+		t.Errorf("Search query failed. Expected 'Wo', got '%s'", got)
+	}
+
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+
+	if ts.GetMode() != ModeNormal {
+		t.Errorf("Enter did not leave search mode. Got %s", ts.GetMode())
+	}
+
+	if _, col := ts.GetCursorPosition(); col != 7 {
+		t.Errorf("Search mode failed. Expected cursorCol = 7, got = %d", col)
+	}
+
+	// Escape cancels without moving
+	ts.handleKeyEvents(runeKey('/'))
+	ts.handleKeyEvents(runeKey('H'))
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone))
+
+	if _, col := ts.GetCursorPosition(); col != 7 || ts.GetMode() != ModeNormal {
+		t.Errorf("Cancelling the search failed. Expected cursorCol = 7 in normal mode, got = %d in %s", col, ts.GetMode())
+	}
+}
+
+func TestSearchExtendsSelection(t *testing.T) {
+	ts := NewTextSel().SetKeymap(VimKeymap()).SetText("Hello, World!")
+
+	ts.StartSelection()
+	ts.handleKeyEvents(runeKey('/'))
+
+	if !ts.isSelecting() {
+		t.Error("Selection was lost when entering search mode")
+	}
+
+	ts.handleKeyEvents(runeKey(','))
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+
+	if ts.GetMode() != ModeVisualChar {
+		t.Errorf("Search did not return to visual mode. Got %s", ts.GetMode())
+	}
+
+	if got := ts.GetSelectedText(); got != "Hello," {
+		t.Errorf("Search failed to extend the selection. Expected 'Hello,', got '%s'", got)
+	}
+}
+
+func TestSearchUnicode(t *testing.T) {
+	ts := NewTextSel().SetText("日本語 and 日本語")
+
+	ts.Search("日本")
+
+	if _, col := ts.GetCursorPosition(); col != 8 {
+		t.Errorf("Unicode search failed. Expected cursorCol = 8, got = %d", col)
+	}
+
+	ts.SearchNext()
+
+	if _, col := ts.GetCursorPosition(); col != 0 {
+		t.Errorf("Unicode search failed to wrap around. Expected cursorCol = 0, got = %d", col)
+	}
+}
+
+func TestSearchQueryChanged(t *testing.T) {
+	var queries []string
+
+	ts := NewTextSel().SetKeymap(VimKeymap()).
+		SetText("Hello, World!").
+		SetSearchQueryChangedFunc(func(query string) { queries = append(queries, query) })
+
+	ts.handleKeyEvents(runeKey('/'))
+	ts.handleKeyEvents(runeKey('W'))
+	ts.handleKeyEvents(runeKey('o'))
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone))
+	ts.handleKeyEvents(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+
+	expected := []string{"W", "Wo", "W", ""}
+
+	if strings.Join(queries, ",") != strings.Join(expected, ",") {
+		t.Errorf("Search query callback failed. Expected %q, got %q", expected, queries)
+	}
+}
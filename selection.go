@@ -5,7 +5,8 @@ import (
 )
 
 // SetSelectFunc sets the callback function that will be called when text is
-// selected.
+// selected. Use SetSelectBlocksFunc to also get the blocks the selected text
+// is in.
 //
 // Example:
 //
@@ -17,9 +18,10 @@ func (ts *TextSel) SetSelectFunc(f func(string)) *TextSel {
 	return ts
 }
 
-// Resets the selection state.
+// Resets the selection state and returns to ModeNormal.
 func (ts *TextSel) ResetSelection() *TextSel {
-	ts.isSelecting = false
+	ts.returnMode = ModeNormal
+	ts.setMode(ModeNormal)
 
 	ts.selectionStartRow = 0
 	ts.selectionStartCol = 0
@@ -46,77 +48,209 @@ func (ts *TextSel) GetSelectionRange() (int, int, int, int) {
 	return startRow, startCol, endRow, endCol
 }
 
+// SetPreserveLineEndings sets whether GetSelectedText returns the line endings
+// of the original text ("\r\n" or "\r"). By default, all line endings in the
+// selected text are converted to "\n".
+//
+// Example:
+//
+//	textSel.SetText(windowsText).SetPreserveLineEndings(true)
+func (ts *TextSel) SetPreserveLineEndings(preserve bool) *TextSel {
+	ts.preserveLineEndings = preserve
+	return ts
+}
+
 // GetSelectedText returns the currently selected text. If no text is selected,
-// an empty string is returned.
+// an empty string is returned. In ModeVisualLine, the selection consists of
+// whole lines including their trailing newlines; in ModeVisualBlock, the
+// selected part of each line is returned, separated by newlines. Line endings
+// are converted to "\n" unless SetPreserveLineEndings is enabled. With
+// SetReturnSource, the source of the selected text is returned instead.
 //
 // Example:
 //
 //	selectedText := textSel.GetSelectedText()
 //	fmt.Println("Selected text:", selectedText)
 func (ts *TextSel) GetSelectedText() string {
-	if !ts.isSelecting {
+	if !ts.isSelecting() {
 		return ""
 	}
 
-	text := ts.text
-	startRow, startCol, endRow, endCol := ts.GetSelectionRange()
+	if ts.returnSource {
+		if text, ok := ts.selectedSource(); ok {
+			return text
+		}
+	}
+
+	lines := ts.getGraphemeLines()
+	startRow, _, endRow, _ := ts.GetSelectionRange()
 
 	buf := strings.Builder{}
-	sel := false
-	row := 0
-	col := 0
-
-	for idx := 0; idx < len(text); idx++ {
-		// Skip any format codes
-		for formatRegex.MatchString(text[idx:]) {
-			match := formatRegex.FindString(text[idx:])
-			idx += len(match)
+
+	for row := startRow; row <= endRow && row < len(lines); row++ {
+		if ts.selectionMode() == ModeVisualBlock && row > startRow {
+			buf.WriteString("\n")
 		}
 
-		char := text[idx]
+		if first, last, ok := ts.selectedColumns(row, lines[row]); ok {
+			for _, char := range lines[row][first : last+1] {
+				if isLineEnding(char) && !ts.preserveLineEndings {
+					char = "\n"
+				}
 
-		if row == startRow && col == startCol {
-			sel = true
+				buf.WriteString(char)
+			}
 		}
+	}
 
-		if sel {
-			buf.WriteString(string(char))
+	return buf.String()
+}
+
+// GetSelectedTextFormatted returns the currently selected text like
+// GetSelectedText, but with its format codes, as text that can be displayed
+// in another tview widget with dynamic colors. It starts with the style in
+// effect at the start of the selection, including the region, and ends by
+// resetting all styles, so that it can be inserted anywhere. If no text is
+// selected, an empty string is returned.
+//
+// Example:
+//
+//	other.SetDynamicColors(true).SetText(textSel.GetSelectedTextFormatted())
+func (ts *TextSel) GetSelectedTextFormatted() string {
+	return runsToTview(ts.selectedRuns())
+}
+
+// Returns the first and last selected column of a row, given the grapheme
+// clusters of the row's line (including its newline). The last return value is
+// false if nothing in the row is selected.
+func (ts *TextSel) selectedColumns(row int, line []string) (int, int, bool) {
+	startRow, startCol, endRow, endCol := ts.GetSelectionRange()
+
+	if !ts.isSelecting() || row < startRow || row > endRow || len(line) == 0 {
+		return 0, 0, false
+	}
+
+	lastCol := len(line) - 1
+
+	switch ts.selectionMode() {
+	case ModeVisualLine:
+		return 0, lastCol, true
+
+	case ModeVisualBlock:
+		// The line ending is never part of a block.
+		if isLineEnding(line[lastCol]) {
+			lastCol--
 		}
 
-		if row == endRow && col == endCol {
-			sel = false
-			break
+		// The block spans the screen cells between the start and the end of
+		// the selection, which may be at different columns in lines with wide
+		// characters.
+		left, right := ts.blockCells()
+		first := ts.columnAtDisplay(line, left)
+
+		if first > lastCol {
+			return 0, 0, false
 		}
 
-		if char == '\n' {
-			row++
-			col = 0
-		} else {
-			col++
+		return first, min(ts.columnAtDisplay(line, right), lastCol), true
+
+	default:
+		first, last := 0, lastCol
+
+		// The cursor may be beyond the end of a line after moving up or down
+		// from a longer one. Treat it as if it were on the last column.
+		if row == startRow {
+			first = min(startCol, lastCol)
 		}
-	}
 
-	return buf.String()
+		if row == endRow {
+			last = min(endCol, lastCol)
+		}
+
+		return first, last, true
+	}
 }
 
-// Starts the selection process at the current position in the document.
+// Starts the selection process at the current position in the document, in
+// ModeVisualChar.
 func (ts *TextSel) StartSelection() *TextSel {
-	ts.isSelecting = true
-	ts.selectionStartRow = ts.cursorRow
-	ts.selectionStartCol = ts.cursorCol
+	return ts.startSelection(ModeVisualChar)
+}
+
+// Starts selecting whole lines, in ModeVisualLine. If a selection is already
+// in progress, it keeps its start and only changes its shape.
+func (ts *TextSel) StartLineSelection() *TextSel {
+	return ts.startSelection(ModeVisualLine)
+}
+
+// Starts selecting a rectangular block, in ModeVisualBlock. If a selection is
+// already in progress, it keeps its start and only changes its shape.
+func (ts *TextSel) StartBlockSelection() *TextSel {
+	return ts.startSelection(ModeVisualBlock)
+}
+
+// Starts a selection in the given visual mode. Starting a selection in the
+// mode that is already active restarts it at the cursor.
+func (ts *TextSel) startSelection(mode Mode) *TextSel {
+	if !ts.isSelecting() || ts.selectionMode() == mode {
+		ts.selectionStartRow = ts.cursorRow
+		ts.selectionStartCol = ts.cursorCol
+	}
+
 	ts.selectionEndRow = ts.cursorRow
 	ts.selectionEndCol = ts.cursorCol
 
+	if ts.mode == ModeSearch || ts.mode == ModePendingOperator {
+		ts.returnMode = mode
+	} else {
+		ts.setMode(mode)
+	}
+
+	ts.highlightCursor()
+
 	return ts
 }
 
-// Finishes the selection process and calls the selectFunc callback.
+// Finishes the selection process, stores the selected text in the registers,
+// copies it to the clipboard (if one is set) and calls the selectFunc and
+// selectBlocksFunc callbacks.
 func (ts *TextSel) FinishSelection() *TextSel {
+	ts.deliverSelection()
+	ts.ResetSelection()
+
+	return ts
+}
+
+// Stores the selected text in the registers, copies it to the clipboard (if
+// one is set) and calls the selectFunc and selectBlocksFunc callbacks.
+func (ts *TextSel) deliverSelection() {
+	text := ts.GetSelectedText()
+	blocks := ts.selectedBlocks()
+
+	if ts.isSelecting() {
+		ts.yank(text)
+
+		if ts.clipboard != nil {
+			ts.copyToClipboard(text)
+		}
+	}
+
 	if ts.selectFunc != nil {
-		ts.selectFunc(ts.GetSelectedText())
+		ts.selectFunc(text)
 	}
 
-	ts.ResetSelection()
+	if ts.selectBlocksFunc != nil {
+		ts.selectBlocksFunc(text, blocks)
+	}
+}
 
-	return ts
+// Returns the first and last screen cell covered by a block selection.
+func (ts *TextSel) blockCells() (int, int) {
+	startLine := graphemes(ts.getLine(ts.selectionStartRow))
+	endLine := graphemes(ts.getLine(ts.selectionEndRow))
+
+	startLeft, startRight := ts.cellSpan(startLine, ts.selectionStartCol)
+	endLeft, endRight := ts.cellSpan(endLine, ts.selectionEndCol)
+
+	return min(startLeft, endLeft), max(startRight, endRight)
 }
@@ -3,6 +3,8 @@ package textsel
 import (
 	"sync"
 	"testing"
+
+	"github.com/rivo/tview"
 )
 
 func TestResetSelection(t *testing.T) {
@@ -120,3 +122,84 @@ func TestSetSelectFunc(t *testing.T) {
 		t.Errorf("SelectFunc failed. Expected %v, got %v", expected, selectedText)
 	}
 }
+
+func TestUnicodeSelection(t *testing.T) {
+	ts := NewTextSel().SetText("[red]Grüße[-], 世界!\nnaïve")
+
+	ts.SetCursorPosition(0, 2).StartSelection().MoveToEndOfLine().MoveLeft()
+
+	if got := ts.GetSelectedText(); got != "üße, 世界!" {
+		t.Errorf("Unicode selection failed. Expected 'üße, 世界!', got: '%s'", got)
+	}
+
+	ts.MoveDown().MoveLeft()
+
+	if got := ts.GetSelectedText(); got != "üße, 世界!\nnaïv" {
+		t.Errorf("Unicode selection across lines failed. Expected 'üße, 世界!\\nnaïv', got: '%s'", got)
+	}
+}
+
+func TestSelectionLineEndings(t *testing.T) {
+	ts := NewTextSel().SetText("Hello\r\nWorld\rFoo")
+
+	// The line ending is a single column
+	ts.StartSelection().MoveToEndOfLine()
+
+	if _, col := ts.GetCursorPosition(); col != 5 {
+		t.Errorf("MoveToEndOfLine with CRLF failed. Expected cursorCol = 5, got = %d", col)
+	}
+
+	ts.MoveDown().MoveToEndOfLine().MoveRight()
+
+	if got := ts.GetSelectedText(); got != "Hello\nWorld\nF" {
+		t.Errorf("Line endings were not normalized. Expected 'Hello\\nWorld\\nF', got: %q", got)
+	}
+
+	ts.SetPreserveLineEndings(true)
+
+	if got := ts.GetSelectedText(); got != "Hello\r\nWorld\rF" {
+		t.Errorf("Line endings were not preserved. Expected 'Hello\\r\\nWorld\\rF', got: %q", got)
+	}
+}
+
+func TestGetSelectedTextFormatted(t *testing.T) {
+	tests := []struct {
+		text       string
+		block      bool
+		start, end [2]int
+		expected   string
+	}{
+		{"[red]ab[::b]cd[-]e\nfg", false, [2]int{0, 1}, [2]int{1, 0}, "[red:black:-:-]b[red:black:-:-][::b]cd[white:black:-:-][::b]e\nf[-:-:-:-]"},
+		{"[x[] [\"r\"]ok[\"\"]", false, [2]int{0, 0}, [2]int{0, 6}, "[white:black:-:-][x[] [\"r\"]ok[\"\"][-:-:-:-]"},
+		{"[red]abc\nd[blue]ef\nghi", true, [2]int{0, 1}, [2]int{2, 1}, "[red:black:-:-]b\n[blue:black:-:-]e\nh[-:-:-:-]"},
+	}
+
+	for _, test := range tests {
+		ts := NewTextSel().SetText(test.text)
+
+		if got := ts.GetSelectedTextFormatted(); got != "" {
+			t.Errorf("GetSelectedTextFormatted without a selection failed. Expected '', got '%s'", got)
+		}
+
+		ts.SetCursorPosition(test.start[0], test.start[1])
+
+		if test.block {
+			ts.StartBlockSelection()
+		} else {
+			ts.StartSelection()
+		}
+
+		ts.SetCursorPosition(test.end[0], test.end[1])
+
+		got := ts.GetSelectedTextFormatted()
+		if got != test.expected {
+			t.Errorf("GetSelectedTextFormatted of %q failed. Expected %q, got %q", test.text, test.expected, got)
+		}
+
+		// Displayed in another widget, it shows the selected text
+		displayed := tview.NewTextView().SetDynamicColors(true).SetRegions(true).SetText(got).GetText(true)
+		if displayed != ts.GetSelectedText() {
+			t.Errorf("GetSelectedTextFormatted of %q failed. Expected it to display %q, got %q", test.text, ts.GetSelectedText(), displayed)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package textsel
+
+import "strings"
+
+// Text that was rendered from an original source, such as text with ANSI
+// escape sequences or Markdown, and can return the source of parts of the
+// displayed text.
+type sourceText interface {
+	// Returns the source of the given ranges of bytes of the displayed text,
+	// or false if the source is not known. The ranges are separate parts of
+	// a block selection unless there is only one.
+	original(ranges [][2]int) (string, bool)
+
+	// Adds displayed text that has no source, such as appended text.
+	skip(n int)
+}
+
+// Maps the displayed text to the source it was rendered from, byte by byte,
+// while writing the displayed text with tags.
+type sourceMap struct {
+	tags *tagWriter
+
+	// For every byte of the displayed text, the range of the source it was
+	// rendered from
+	starts []int
+	ends   []int
+}
+
+// Creates an empty source map.
+func newSourceMap() *sourceMap {
+	return &sourceMap{tags: newTagWriter(&strings.Builder{})}
+}
+
+// Writes displayed text rendered from the given range of the source.
+func (m *sourceMap) text(text string, start, end int) {
+	m.tags.WriteText(text)
+
+	for i := 0; i < len(text); i++ {
+		m.starts = append(m.starts, start)
+		m.ends = append(m.ends, end)
+	}
+}
+
+// Adds n bytes of displayed text that were not rendered from the source and
+// have no source.
+func (m *sourceMap) skip(n int) {
+	for i := 0; i < n; i++ {
+		m.starts = append(m.starts, -1)
+		m.ends = append(m.ends, -1)
+	}
+}
+
+// Writes a tag, which is not displayed.
+func (m *sourceMap) tag(tag string) {
+	m.tags.WriteTag(tag)
+}
+
+// Extends the source of the displayed text from byte from to byte to to the
+// given range, e.g. to include the markup around it. The first byte starts and
+// the last byte ends no later than the range.
+func (m *sourceMap) extend(from, to int, start, end int) {
+	if from >= to || to > len(m.starts) {
+		return
+	}
+
+	m.starts[from] = min(m.starts[from], start)
+	m.ends[to-1] = max(m.ends[to-1], end)
+}
+
+// Returns the displayed text written so far, and starts a new one.
+func (m *sourceMap) take() string {
+	text := m.tags.String()
+	m.tags.Reset()
+
+	return text
+}
+
+// Returns true if the ranges of the displayed text are non-empty and all of
+// their bytes were rendered from the source.
+func (m *sourceMap) covers(ranges [][2]int) bool {
+	if len(ranges) == 0 {
+		return false
+	}
+
+	for _, r := range ranges {
+		if r[0] >= r[1] || r[1] > len(m.starts) {
+			return false
+		}
+
+		for i := r[0]; i < r[1]; i++ {
+			if m.starts[i] < 0 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// SetReturnSource sets whether GetSelectedText returns the source of the
+// selected text instead of the text as displayed, if the text was set with
+// SetMarkdown, SetANSIText or ANSIWriter. The source is the Markdown or the
+// text with ANSI escape sequences that the selected text was rendered from.
+// The default is false.
+//
+// Example:
+//
+//	textSel.SetMarkdown(answer).SetReturnSource(true)
+func (ts *TextSel) SetReturnSource(enabled bool) *TextSel {
+	ts.returnSource = enabled
+	return ts
+}
+
+// Returns the source of the selected text, if the text was rendered from a
+// source.
+func (ts *TextSel) selectedSource() (string, bool) {
+	if ts.source == nil || !ts.isSelecting() {
+		return "", false
+	}
+
+	lines := ts.getGraphemeLines()
+	startRow, _, endRow, _ := ts.GetSelectionRange()
+	ranges := [][2]int{}
+
+	for row := startRow; row <= endRow && row < len(lines); row++ {
+		first, last, ok := ts.selectedColumns(row, lines[row])
+		if !ok {
+			continue
+		}
+
+		start := ts.offsetOf(row, first)
+		end := start + len(strings.Join(lines[row][first:last+1], ""))
+
+		// Outside of block mode, the selection is contiguous
+		if n := len(ranges) - 1; n >= 0 && ts.selectionMode() != ModeVisualBlock {
+			ranges[n][1] = end
+		} else {
+			ranges = append(ranges, [2]int{start, end})
+		}
+	}
+
+	text, ok := ts.source.original(ranges)
+	if ok && !ts.preserveLineEndings {
+		text = lineEndingReplacer.Replace(text)
+	}
+
+	return text, ok
+}
+
+// Converts all line endings to "\n".
+var lineEndingReplacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
@@ -3,74 +3,168 @@ package textsel
 import (
 	"fmt"
 	"strings"
+	"unicode"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
+// The style of text as set by tview's style tags: colors, attributes and the
+// URL of a hyperlink. Each of them is changed independently by a tag.
 type formatCode struct {
 	fg    tcell.Color
 	bg    tcell.Color
-	style string
+	attrs tcell.AttrMask
+	url   string
 }
 
+// Attribute flags of style tags, in the order they are written.
+var attributeFlags = []struct {
+	flag byte
+	attr tcell.AttrMask
+}{
+	{'b', tcell.AttrBold},
+	{'i', tcell.AttrItalic},
+	{'u', tcell.AttrUnderline},
+	{'d', tcell.AttrDim},
+	{'r', tcell.AttrReverse},
+	{'l', tcell.AttrBlink},
+	{'s', tcell.AttrStrikeThrough},
+}
+
+// Returns the style of text without any tags, which is also the style that
+// "-" resets a field to.
 func newFormatCode() formatCode {
 	return formatCode{
-		fg:    tview.Styles.PrimaryTextColor,
-		bg:    tview.Styles.PrimitiveBackgroundColor,
-		style: "",
+		fg: tview.Styles.PrimaryTextColor,
+		bg: tview.Styles.PrimitiveBackgroundColor,
 	}
 }
 
+// Returns the attribute flags of the style, e.g. "bu", or "" if it has none.
+func (fc formatCode) flags() string {
+	flags := ""
+
+	for _, f := range attributeFlags {
+		if fc.attrs&f.attr != 0 {
+			flags += string(f.flag)
+		}
+	}
+
+	return flags
+}
+
+// Returns tags that set exactly this style, whatever the previous one was.
 func (fc formatCode) String() string {
-	return fmt.Sprintf("[%s:%s:%s]", fc.fg.String(), fc.bg.String(), fc.style)
+	url := fc.url
+	if url == "" {
+		url = "-"
+	}
+
+	code := fmt.Sprintf("[%s:%s:-:%s]", fc.fg, fc.bg, url)
+
+	// Attribute flags add to the current attributes, so they follow a reset.
+	if flags := fc.flags(); flags != "" {
+		code += "[::" + flags + "]"
+	}
+
+	return code
 }
 
+// Returns the style after applying a tag to it. Like tview, a field that is
+// empty or missing from the tag leaves it unchanged, and "-" resets it. Lower
+// case attribute flags add an attribute and upper case ones remove it. Region
+// tags don't change the style.
 func (fc formatCode) update(code string) formatCode {
-	// Strip the [ and ] characters from the code
-	code = strings.TrimLeft(code, "[")
-	code = strings.TrimRight(code, "]")
+	if strings.HasPrefix(code, `["`) {
+		return fc
+	}
 
-	// Split the code into its components
-	parts := strings.Split(code, ":")
+	// Strip the [ and ] characters from the code, and split it into its
+	// components. The URL may contain colons.
+	code = strings.TrimSuffix(strings.TrimPrefix(code, "["), "]")
+	parts := strings.SplitN(code, ":", 4)
+	initial := newFormatCode()
 
-	if len(parts) > 0 {
-		switch parts[0] {
-		case "":
-			// current style persists
-		case "-":
-			fc.fg = tview.Styles.PrimaryTextColor
+	color := func(name string, current, reset tcell.Color) tcell.Color {
+		switch {
+		case name == "":
+			return current
+		case name == "-":
+			return reset
+		case strings.HasPrefix(name, "#"):
+			return tcell.GetColor(name)
 		default:
-			fc.fg = tcell.GetColor(parts[0])
+			return tcell.ColorNames[name]
 		}
-
-		fc.bg = tview.Styles.PrimitiveBackgroundColor
-		fc.style = ""
 	}
 
+	fc.fg = color(parts[0], fc.fg, initial.fg)
+
 	if len(parts) > 1 {
-		switch parts[1] {
-		case "":
-			// current style persists
-		case "-":
-			fc.bg = tview.Styles.PrimitiveBackgroundColor
-		default:
-			fc.bg = tcell.GetColor(parts[1])
+		fc.bg = color(parts[1], fc.bg, initial.bg)
+	}
+
+	if len(parts) > 2 {
+		if parts[2] == "-" {
+			fc.attrs = initial.attrs
 		}
 
-		fc.style = ""
+		for _, ch := range parts[2] {
+			for _, f := range attributeFlags {
+				switch {
+				case ch == rune(f.flag):
+					fc.attrs |= f.attr
+				case ch == unicode.ToUpper(rune(f.flag)):
+					fc.attrs &^= f.attr
+				}
+			}
+		}
 	}
 
-	if len(parts) > 2 {
-		switch parts[2] {
+	if len(parts) > 3 {
+		switch parts[3] {
 		case "":
-			// current style persists
+			// current URL persists
 		case "-":
-			fc.style = "-"
+			fc.url = ""
 		default:
-			fc.style = parts[2]
+			fc.url = parts[3]
 		}
 	}
 
 	return fc
 }
+
+// SetPreserveSelectionStyles sets whether selected text keeps the colors and
+// attributes given by format codes in the text. If enabled, which is the
+// default, only the background of selected text changes to that of the
+// selection, so syntax highlighting remains visible. Text in the default color
+// takes the selection's foreground to keep it readable. If disabled, selected
+// text is displayed entirely in the selection colors.
+//
+// Example:
+//
+//	textSel.SetPreserveSelectionStyles(false)
+func (ts *TextSel) SetPreserveSelectionStyles(preserve bool) *TextSel {
+	ts.preserveSelectionStyles = preserve
+	ts.highlightCursor()
+	return ts
+}
+
+// Returns the format code for selected text with the given format.
+func (ts *TextSel) selectionStyle(fc formatCode) string {
+	if !ts.preserveSelectionStyles {
+		return ts.selectionColor
+	}
+
+	selection := newFormatCode().update(ts.selectionColor)
+	if fc.fg != tview.Styles.PrimaryTextColor {
+		selection.fg = fc.fg
+	}
+
+	selection.attrs = fc.attrs
+	selection.url = fc.url
+
+	return selection.String()
+}
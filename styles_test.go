@@ -1,6 +1,7 @@
 package textsel
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/gdamore/tcell/v2"
@@ -18,8 +19,12 @@ func TestNewFormatCode(t *testing.T) {
 		t.Errorf("newFormatCode() failed. Expected bg = '%v', got = '%v'", tview.Styles.PrimitiveBackgroundColor, fc.bg)
 	}
 
-	if fc.style != "" {
-		t.Errorf("newFormatCode() failed. Expected style = '', got = '%v'", fc.style)
+	if fc.attrs != 0 {
+		t.Errorf("newFormatCode() failed. Expected attrs = 0, got = '%v'", fc.attrs)
+	}
+
+	if fc.url != "" {
+		t.Errorf("newFormatCode() failed. Expected url = '', got = '%v'", fc.url)
 	}
 }
 
@@ -27,10 +32,10 @@ func TestFormatCodeString(t *testing.T) {
 	fc := formatCode{
 		fg:    tcell.ColorRed,
 		bg:    tcell.ColorBlue,
-		style: "b",
+		attrs: tcell.AttrBold,
 	}
 
-	expected := "[red:blue:b]"
+	expected := "[red:blue:-:-][::b]"
 	got := fc.String()
 
 	if got != expected {
@@ -52,9 +57,9 @@ func TestUpdateFormatCode1(t *testing.T) {
 		t.Errorf("update() failed. Expected bg = '%v', got = '%v'", expectedBg, updated.bg)
 	}
 
-	expectedStyle := ""
-	if updated.style != expectedStyle {
-		t.Errorf("update() failed. Expected style = '%v', got = '%v'", expectedStyle, updated.style)
+	expectedAttrs := tcell.AttrNone
+	if updated.attrs != expectedAttrs {
+		t.Errorf("update() failed. Expected attrs = '%v', got = '%v'", expectedAttrs, updated.attrs)
 	}
 }
 
@@ -72,9 +77,9 @@ func TestUpdateFormatCode2(t *testing.T) {
 		t.Errorf("update() failed. Expected bg = '%v', got = '%v'", expectedBg, updated.bg)
 	}
 
-	expectedStyle := "-"
-	if updated.style != expectedStyle {
-		t.Errorf("update() failed. Expected style = '%v', got = '%v'", expectedStyle, updated.style)
+	expectedAttrs := tcell.AttrNone
+	if updated.attrs != expectedAttrs {
+		t.Errorf("update() failed. Expected attrs = '%v', got = '%v'", expectedAttrs, updated.attrs)
 	}
 }
 
@@ -92,9 +97,9 @@ func TestUpdateFormatCode3(t *testing.T) {
 		t.Errorf("update() failed. Expected bg = '%v', got = '%v'", expectedBg, updated.bg)
 	}
 
-	expectedStyle := "b"
-	if updated.style != expectedStyle {
-		t.Errorf("update() failed. Expected style = '%v', got = '%v'", expectedStyle, updated.style)
+	expectedAttrs := tcell.AttrBold
+	if updated.attrs != expectedAttrs {
+		t.Errorf("update() failed. Expected attrs = '%v', got = '%v'", expectedAttrs, updated.attrs)
 	}
 }
 
@@ -112,8 +117,74 @@ func TestUpdateFormatCode4(t *testing.T) {
 		t.Errorf("update() failed. Expected bg = '%v', got = '%v'", expectedBg, updated.bg)
 	}
 
-	expectedStyle := "i"
-	if updated.style != expectedStyle {
-		t.Errorf("update() failed. Expected style = '%v', got = '%v'", expectedStyle, updated.style)
+	expectedAttrs := tcell.AttrItalic
+	if updated.attrs != expectedAttrs {
+		t.Errorf("update() failed. Expected attrs = '%v', got = '%v'", expectedAttrs, updated.attrs)
+	}
+}
+
+// Returns the style with which tview displays the last character of text.
+func renderedStyle(text string) (tcell.Style, string) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	screen.Init()
+	screen.SetSize(80, 1)
+
+	textView := tview.NewTextView().SetDynamicColors(true).SetRegions(true).SetText(text)
+	textView.SetRect(0, 0, 80, 1)
+	textView.Draw(screen)
+
+	_, _, style, _ := screen.GetContent(tview.TaggedStringWidth(text)-1, 0)
+	url := reflect.ValueOf(style).FieldByName("url").String()
+
+	return style, url
+}
+
+func TestFormatCodeMatchesTview(t *testing.T) {
+	tests := []string{
+		"",
+		"[red]",
+		"[#ff8000]",
+		"[:blue]",
+		"[red:blue]",
+		"[::b]",
+		"[::b][red]",
+		"[::b][:blue]",
+		"[red:blue:b][-]",
+		"[red:blue:b][:-]",
+		"[red:blue:b][::-]",
+		"[red:blue:b][-:-:-]",
+		"[::bu][::i]",
+		"[::bui][::U]",
+		"[::bilsdru][::BD]",
+		"[::r][::-][::l]",
+		"[:::https://example.com]",
+		"[:::https://example.com][red]",
+		"[:::https://example.com][:::-]",
+		"[red::b:https://example.com][::-]",
+		"[yellow:#004000:s][\"r\"][:]",
+		"[red][\"r\"][::d][\"\"]",
+		"[green:blue:bu][-:-]",
+	}
+
+	for _, tags := range tests {
+		fc := newFormatCode()
+		for _, token := range splitTags(tags) {
+			fc = fc.update(token.raw)
+		}
+
+		expected, expectedURL := renderedStyle(tags + "x")
+		fg, bg, attrs := expected.Decompose()
+
+		if fc.fg != fg || fc.bg != bg || fc.attrs != attrs || fc.url != expectedURL {
+			t.Errorf("update() with %q failed. Expected %v/%v/%v/%q, got %v/%v/%v/%q", tags, fg, bg, attrs, expectedURL, fc.fg, fc.bg, fc.attrs, fc.url)
+		}
+
+		// The string sets exactly the same style, whatever the previous one
+		got, gotURL := renderedStyle("[green:blue:bu:https://other.org]z" + fc.String() + "x")
+		gotFg, gotBg, gotAttrs := got.Decompose()
+
+		if gotFg != fg || gotBg != bg || gotAttrs != attrs || gotURL != expectedURL {
+			t.Errorf("String() of %q failed. Expected %v/%v/%v/%q, got %v/%v/%v/%q", tags, fg, bg, attrs, expectedURL, gotFg, gotBg, gotAttrs, gotURL)
+		}
 	}
 }
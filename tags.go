@@ -0,0 +1,355 @@
+package textsel
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Matches an escaped tag, e.g. "[red[]", which tview displays as "[red]".
+var escapedTagPattern = regexp.MustCompile(`^\[[^\[\]]+\[+\]`)
+
+// A token of text with tview tags. Tags have an empty char. All other tokens
+// are a single grapheme cluster of displayed text, whose raw form may differ
+// from the displayed one in escaped tags.
+type textToken struct {
+	raw  string
+	char string
+}
+
+// Returns true if the token is a style or region tag.
+func (t textToken) isTag() bool {
+	return t.char == ""
+}
+
+// Returns true if the token is a region tag, e.g. `["id"]`.
+func (t textToken) isRegionTag() bool {
+	return strings.HasPrefix(t.raw, `["`)
+}
+
+// Splits text into tags and grapheme clusters, following the tag syntax of
+// tview's TextView (with dynamic colors and regions enabled): style tags such
+// as "[red]", "[#ff0000:-:bu]" or "[:::https://example.com]", region tags such
+// as `["id"]`, and escaped tags such as "[red[]". Anything that is not a
+// valid tag is text.
+//
+// The clusters are those of the text as displayed, so that they match the
+// columns of the stripped text. A tag inside a cluster, e.g. between a letter
+// and its combining mark, follows the cluster instead.
+func splitTags(text string) []textToken {
+	runes := []textToken{}
+	escapeEnd := -1
+
+	for idx := 0; idx < len(text); {
+		if idx > escapeEnd && text[idx] == '[' {
+			if length := tagLength(text[idx:]); length > 0 {
+				runes = append(runes, textToken{raw: text[idx : idx+length]})
+				idx += length
+				continue
+			}
+
+			// In an escaped tag, the first "[" of the closing brackets is not
+			// displayed.
+			if match := escapedTagPattern.FindString(text[idx:]); match != "" {
+				escapeEnd = idx + strings.IndexByte(match[1:], '[') + 1
+			}
+		}
+
+		raw := ""
+		if idx == escapeEnd {
+			raw = "["
+		}
+
+		_, size := utf8.DecodeRuneInString(text[idx+len(raw):])
+		raw = text[idx : idx+len(raw)+size]
+
+		runes = append(runes, textToken{raw: raw, char: raw[len(raw)-size:]})
+		idx += len(raw)
+	}
+
+	// Join the runes into grapheme clusters of the displayed text
+	displayed := strings.Builder{}
+	for _, r := range runes {
+		displayed.WriteString(r.char)
+	}
+
+	tokens := []textToken{}
+	next := 0
+
+	for _, char := range graphemes(displayed.String()) {
+		cluster := textToken{}
+		tags := []textToken{}
+
+		for len(cluster.char) < len(char) {
+			switch r := runes[next]; {
+			case !r.isTag():
+				cluster.raw += r.raw
+				cluster.char += r.char
+			case cluster.char == "":
+				tokens = append(tokens, r)
+			default:
+				tags = append(tags, r)
+			}
+
+			next++
+		}
+
+		tokens = append(tokens, cluster)
+		tokens = append(tokens, tags...)
+	}
+
+	return append(tokens, runes[next:]...)
+}
+
+// Returns the text with all tags removed and escaped tags unescaped, i.e. the
+// text as it is displayed.
+func stripTags(text string) string {
+	buf := strings.Builder{}
+
+	for _, token := range splitTags(text) {
+		buf.WriteString(token.char)
+	}
+
+	return buf.String()
+}
+
+// Returns the length of the style or region tag at the start of text, or 0 if
+// text does not start with a valid tag. This mirrors the parser in tview.
+func tagLength(text string) int {
+	const (
+		start = iota
+		foreground
+		foregroundName
+		foregroundHex
+		endForeground
+		background
+		backgroundName
+		backgroundHex
+		endBackground
+		attributes
+		attributeFlags
+		endAttributes
+		url
+		urlChars
+		endURL
+		region
+		regionName
+		endRegion
+	)
+
+	isNameChar := func(b byte) bool {
+		return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+	}
+
+	isRegionChar := func(b byte) bool {
+		return isNameChar(b) || strings.IndexByte("_,;: -.", b) >= 0
+	}
+
+	isHexDigit := func(b byte) bool {
+		return strings.IndexByte("0123456789abcdefABCDEF", b) >= 0
+	}
+
+	isFlag := func(b byte) bool {
+		return strings.IndexByte("buildsrBUILDSR", b) >= 0
+	}
+
+	if len(text) == 0 || text[0] != '[' {
+		return 0
+	}
+
+	state := start
+	nameStart := 0
+
+	for idx := 1; idx < len(text); idx++ {
+		ch := text[idx]
+
+		switch state {
+		case start:
+			switch {
+			case ch == '"':
+				state = region
+			case ch == '-':
+				state = endForeground
+			case ch == ':':
+				state = background
+			case ch == '#':
+				state, nameStart = foregroundHex, idx
+			case ch >= '0' && ch <= '9':
+				// Color names must not start with a digit
+				return 0
+			case isNameChar(ch):
+				state = foregroundName
+			default:
+				return 0
+			}
+		case foregroundName, backgroundName:
+			switch {
+			case ch == ']':
+				return idx + 1
+			case ch == ':' && state == foregroundName:
+				state = background
+			case ch == ':':
+				state = attributes
+			case !isNameChar(ch):
+				return 0
+			}
+		case foregroundHex, backgroundHex:
+			switch {
+			case ch == ']' || ch == ':':
+				if idx-nameStart != 7 {
+					return 0
+				}
+
+				if ch == ']' {
+					return idx + 1
+				}
+
+				if state == foregroundHex {
+					state = background
+				} else {
+					state = attributes
+				}
+			case !isHexDigit(ch):
+				return 0
+			}
+		case endForeground, endBackground:
+			switch {
+			case ch == ']':
+				return idx + 1
+			case ch == ':' && state == endForeground:
+				state = background
+			case ch == ':':
+				state = attributes
+			default:
+				return 0
+			}
+		case background:
+			switch {
+			case ch == ']':
+				return idx + 1
+			case ch == '-':
+				state = endBackground
+			case ch == ':':
+				state = attributes
+			case ch == '#':
+				state, nameStart = backgroundHex, idx
+			case ch >= '0' && ch <= '9':
+				return 0
+			case isNameChar(ch):
+				state = backgroundName
+			default:
+				return 0
+			}
+		case attributes, attributeFlags:
+			switch {
+			case ch == ']':
+				return idx + 1
+			case ch == ':':
+				state = url
+			case ch == '-' && state == attributes:
+				state = endAttributes
+			case isFlag(ch):
+				state = attributeFlags
+			default:
+				return 0
+			}
+		case endAttributes:
+			switch ch {
+			case ']':
+				return idx + 1
+			case ':':
+				state = url
+			default:
+				return 0
+			}
+		case url:
+			switch ch {
+			case ']':
+				return idx + 1
+			case '-':
+				state = endURL
+			default:
+				state = urlChars
+			}
+		case urlChars:
+			if ch == ']' {
+				return idx + 1
+			}
+		case endURL:
+			if ch != ']' {
+				return 0
+			}
+
+			return idx + 1
+		case region, regionName:
+			switch {
+			case ch == '"':
+				state = endRegion
+			case isRegionChar(ch):
+				state = regionName
+			default:
+				return 0
+			}
+		case endRegion:
+			if ch != ']' {
+				return 0
+			}
+
+			return idx + 1
+		}
+	}
+
+	return 0
+}
+
+// Writes text for display in a TextView. Displayed "]" characters that would
+// otherwise close a tag together with the preceding text are escaped.
+type tagWriter struct {
+	*strings.Builder
+
+	// Whether the text written since the last tag could be the start of a
+	// tag: 0 if not, 1 after "[", 2 after "[" and tag characters, and 3 after
+	// those and further "["
+	state int
+}
+
+// Creates a tagWriter writing to buf.
+func newTagWriter(buf *strings.Builder) *tagWriter {
+	return &tagWriter{Builder: buf}
+}
+
+// Writes a tag unchanged.
+func (w *tagWriter) WriteTag(tag string) {
+	w.Builder.WriteString(tag)
+	w.state = 0
+}
+
+// Writes displayed text, escaping it where necessary.
+func (w *tagWriter) WriteText(text string) {
+	for idx := 0; idx < len(text); idx++ {
+		ch := text[idx]
+
+		switch {
+		case ch == '[':
+			if w.state >= 2 {
+				w.state = 3
+			} else {
+				w.state = 1
+			}
+		case ch == ']':
+			if w.state >= 2 {
+				w.Builder.WriteByte('[')
+			}
+
+			w.state = 0
+		case ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || strings.IndexByte(`_,;: -."#`, ch) >= 0:
+			if w.state > 0 {
+				w.state = 2
+			}
+		default:
+			w.state = 0
+		}
+
+		w.Builder.WriteByte(ch)
+	}
+}
@@ -0,0 +1,181 @@
+package textsel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rivo/tview"
+)
+
+func TestTagLength(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"[red]x", 5},
+		{"[-]", 3},
+		{"[:]", 3},
+		{"[-:-:-]", 7},
+		{"[#ff0000]", 9},
+		{"[#ff0000:#00FF00:bu]", 20},
+		{"[yellow::b]", 11},
+		{"[::bI]", 6},
+		{"[:::https://example.com]", 24},
+		{"[:::-]", 6},
+		{`["id"]`, 6},
+		{`[""]`, 4},
+		{`["a b.c"]`, 9},
+		{"[]", 0},
+		{"[123]", 0},
+		{"[#fff]", 0},
+		{"[red", 0},
+		{"[red[]", 0},
+		{"[a b]", 0},
+		{"[::x]", 0},
+		{"[::b-]", 0},
+		{`["id]`, 0},
+		{"red]", 0},
+	}
+
+	for _, test := range tests {
+		if got := tagLength(test.text); got != test.expected {
+			t.Errorf("tagLength(%q) failed. Expected %d, got %d", test.text, test.expected, got)
+		}
+	}
+}
+
+func TestStripTags(t *testing.T) {
+	tests := []string{
+		"plain text",
+		"[red]red[-] and [#ff0000:#000000:b]hex[-:-:-]",
+		`["id"]region[""] text`,
+		"[:::https://example.com]link[:::-]",
+		"[red[] is escaped, [red[[] too",
+		"[123] and [] and [a b] are text",
+		"[x][y[]",
+		"a[b[c]d]",
+		"日本[red]語[-]",
+	}
+
+	for _, text := range tests {
+		expected := tview.NewTextView().SetDynamicColors(true).SetRegions(true).SetText(text).GetText(true)
+
+		if got := stripTags(text); got != expected {
+			t.Errorf("stripTags(%q) failed. Expected %q, got %q", text, expected, got)
+		}
+	}
+}
+
+func TestSplitTagsClusters(t *testing.T) {
+	tests := []string{
+		"e[red]\u0301x",
+		"[::b]a\u0301[-]b",
+		"🇩[red]🇪 [\"r\"]👍\u200d[\"\"]🔥",
+		"[x[]\u0301",
+	}
+
+	// Tags can't split grapheme clusters, so the columns match the stripped text
+	for _, text := range tests {
+		chars := []string{}
+		for _, token := range splitTags(text) {
+			if !token.isTag() {
+				chars = append(chars, token.char)
+			}
+		}
+
+		expected := graphemes(stripTags(text))
+		if len(chars) != len(expected) {
+			t.Errorf("splitTags(%q) failed. Expected %q, got %q", text, expected, chars)
+			continue
+		}
+
+		for i := range expected {
+			if chars[i] != expected[i] {
+				t.Errorf("splitTags(%q) failed. Expected %q, got %q", text, expected, chars)
+				break
+			}
+		}
+	}
+
+	// A tag inside a cluster follows it
+	tokens := splitTags("e[red]\u0301x")
+	expected := []string{"e\u0301", "[red]", "x"}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("splitTags failed. Expected %d tokens, got %d", len(expected), len(tokens))
+	}
+
+	for i, token := range tokens {
+		if token.raw != expected[i] {
+			t.Errorf("splitTags failed. Expected token %d = %q, got %q", i, expected[i], token.raw)
+		}
+	}
+
+	// The cursor in the second column is on the x, not the combining mark
+	ts := NewTextSel()
+
+	app := tview.NewApplication()
+	app.SetRoot(ts, true)
+	app.SetFocus(ts)
+
+	ts.SetText("e[red]\u0301x").SetCursorPosition(0, 1).highlightCursor()
+
+	highlighted := "e\u0301[red][black:white:-]x[red:black:-:-]"
+	if got := ts.TextView.GetText(false); got != highlighted {
+		t.Errorf("Cursor highlight failed. Expected %q, got %q", highlighted, got)
+	}
+}
+
+func TestGetTextTags(t *testing.T) {
+	ts := NewTextSel()
+	ts.SetText("[#ff0000]a[-] [\"r\"]b[\"\"] [:::https://x.org]c[:::-] [d[]")
+
+	expected := "a b c [d]"
+	if got := ts.GetText(true); got != expected {
+		t.Errorf("GetText failed. Expected %q, got %q", expected, got)
+	}
+
+	// Columns count displayed characters, so moving to "c" selects it
+	ts.SetCursorPosition(0, 4)
+	ts.StartSelection()
+	ts.MoveRight()
+
+	if got := ts.GetSelectedText(); got != "c " {
+		t.Errorf("GetSelectedText failed. Expected %q, got %q", "c ", got)
+	}
+}
+
+func TestHighlightEscapedTags(t *testing.T) {
+	ts := NewTextSel()
+
+	app := tview.NewApplication()
+	app.SetRoot(ts, true)
+	app.SetFocus(ts)
+
+	text := "[red[] [x][y[] [#fff] [\"r\"]a[\"\"]\n[b[[] c]"
+	ts.SetText(text)
+
+	// However the cursor and selection are highlighted, the displayed text
+	// must stay the same.
+	expected := strings.ReplaceAll(ts.GetText(true), "\n", " \n")
+
+	for row := 0; row <= ts.lastRow(); row++ {
+		for col := 0; col < lineLength(ts.getLine(row)); col++ {
+			ts.ResetSelection()
+			ts.SetCursorPosition(row, col)
+			ts.highlightCursor()
+
+			if got := ts.TextView.GetText(true); got != expected {
+				t.Errorf("Cursor at (%d, %d) failed. Expected %q, got %q", row, col, expected, got)
+			}
+
+			ts.StartSelection()
+			ts.MoveRight()
+			ts.highlightCursor()
+
+			if got := ts.TextView.GetText(true); got != expected {
+				t.Errorf("Selection at (%d, %d) failed. Expected %q, got %q", row, col, expected, got)
+			}
+		}
+	}
+}
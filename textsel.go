@@ -3,27 +3,52 @@ package textsel
 
 import (
 	"fmt"
-	"regexp"
+	"strings"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
-var formatRegex = regexp.MustCompile(`^\[[-:a-zA-Z0-9]+\]`)
-var formatRegexGlobal = regexp.MustCompile(`\[[-:a-zA-Z0-9]+\]`)
-
 // TextSel is a `tview.TextView` widget that supports selecting text with the keyboard.
 type TextSel struct {
 	*tview.TextView
 
 	text string
 
+	// The text without format codes, as a whole, split into lines and split
+	// into grapheme clusters, and the words of the lines (see wordIndexes),
+	// computed when first needed after the text changes
+	strippedText  string
+	lines         []string
+	graphemeLines [][]string
+	words         [][]int
+
 	// Cursor position
 	cursorRow int
 	cursorCol int
 
+	// Current mode, and the mode to return to after a search or pending
+	// operator
+	mode       Mode
+	returnMode Mode
+	modeFunc   func(from, to Mode)
+
+	// Distance between tab stops
+	tabWidth int
+
+	// Whether right-to-left text is displayed and navigated in visual order
+	bidi bool
+
+	// Characters that are part of words in addition to letters and digits
+	keywordChars string
+
+	// Position and screen column of the cursor after the last vertical
+	// movement, used to keep the screen column across consecutive ones
+	verticalRow  int
+	verticalCol  int
+	verticalCell int
+
 	// Selection state
-	isSelecting       bool
 	selectionStartRow int
 	selectionStartCol int
 	selectionEndRow   int
@@ -35,8 +60,83 @@ type TextSel struct {
 	selectionColor         string
 	cursorInSelectionColor string
 
-	// Callback for handling selected text
-	selectFunc func(string)
+	// Whether selected text keeps the original line endings
+	preserveLineEndings bool
+
+	// Whether selected text keeps the colors and attributes of the text
+	preserveSelectionStyles bool
+
+	// Whether the region under the cursor is highlighted
+	highlightRegion bool
+
+	// The source the text was rendered from, if any, and whether
+	// GetSelectedText returns it
+	source       sourceText
+	returnSource bool
+
+	// Spans of the text defined by the application
+	blocks []Block
+
+	// Callbacks for handling selected text
+	selectFunc       func(string)
+	selectBlocksFunc func(string, []Block)
+
+	// Destination for selected text, and a channel that is closed when the
+	// last copy to it has finished
+	clipboard Clipboard
+	lastCopy  chan struct{}
+
+	// Position at which the pending operator was started
+	operatorRow int
+	operatorCol int
+
+	// Search state: the query being typed and the callback notified of its
+	// changes, and the last submitted query and its direction
+	searchQuery     string
+	searchQueryFunc func(query string)
+	lastSearch      string
+	lastSearchBack  bool
+
+	// Yank registers
+	registers       map[rune]string
+	pendingRegister rune
+	registerFunc    func(name rune, text string)
+
+	// External command for piping selections, and the callback receiving its
+	// output
+	pipeCommand []string
+	pipeFunc    func(PipeResult)
+
+	// Registry of named actions
+	actions map[string]Action
+
+	// Key bindings, the keys typed so far of a multi-key sequence, the count
+	// typed before them and the count typed before a pending operator
+	keymap        *Keymap
+	pendingKeys   []string
+	count         int
+	operatorCount int
+
+	// Key binding help, and the callback that displays it
+	helpView *HelpView
+	helpFunc func(help *HelpView)
+
+	// Callbacks for keys before the keymap sees them, and for keys that are
+	// not bound in the keymap
+	inputCapture     func(event *tcell.EventKey) *tcell.EventKey
+	unhandledKeyFunc func(event *tcell.EventKey) *tcell.EventKey
+
+	// Cursor position at the time of the last draw, used to decide whether
+	// the view must be scrolled to follow the cursor
+	drawnCursorRow int
+	drawnCursorCol int
+
+	// Whether the widget had focus at the time of the last draw, used to
+	// decide whether the cursor must be shown or hidden
+	drawnFocus bool
+
+	// Callback for reporting errors
+	errorFunc func(error)
 }
 
 // NewTextSel creates and returns a new TextSel instance.
@@ -51,26 +151,18 @@ func NewTextSel() *TextSel {
 		SetWordWrap(true)
 
 	ts := &TextSel{
-		TextView:               textView,
-		defaultColor:           fmt.Sprintf("[%s:%s:-]", tview.Styles.PrimaryTextColor, tview.Styles.PrimitiveBackgroundColor),
-		cursorColor:            fmt.Sprintf("[%s:%s:-]", tview.Styles.PrimitiveBackgroundColor, tview.Styles.PrimaryTextColor),
-		selectionColor:         fmt.Sprintf("[%s:%s:-]", tview.Styles.PrimitiveBackgroundColor, tview.Styles.SecondaryTextColor),
-		cursorInSelectionColor: fmt.Sprintf("[%s:%s:bu]", tview.Styles.PrimitiveBackgroundColor, tview.Styles.SecondaryTextColor),
+		TextView:                textView,
+		defaultColor:            fmt.Sprintf("[%s:%s:-]", tview.Styles.PrimaryTextColor, tview.Styles.PrimitiveBackgroundColor),
+		cursorColor:             fmt.Sprintf("[%s:%s:-]", tview.Styles.PrimitiveBackgroundColor, tview.Styles.PrimaryTextColor),
+		selectionColor:          fmt.Sprintf("[%s:%s:-]", tview.Styles.PrimitiveBackgroundColor, tview.Styles.SecondaryTextColor),
+		cursorInSelectionColor:  fmt.Sprintf("[%s:%s:bu]", tview.Styles.PrimitiveBackgroundColor, tview.Styles.SecondaryTextColor),
+		preserveSelectionStyles: true,
+		tabWidth:                tview.TabSize,
+		keywordChars:            "_",
+		actions:                 newActionRegistry(),
+		keymap:                  DefaultKeymap(),
 	}
 
-	// Handle key events for moving the cursor and selecting text
-	ts.SetInputCapture(ts.handleKeyEvents)
-
-	// Ensure that we redraw when we are focused or blurred to update whether
-	// the cursor is visible or not.
-	ts.SetFocusFunc(func() {
-		go ts.highlightCursor()
-	})
-
-	ts.SetBlurFunc(func() {
-		go ts.highlightCursor()
-	})
-
 	ts.ResetCursor()
 	ts.ResetSelection()
 
@@ -84,59 +176,163 @@ func NewTextSel() *TextSel {
 //
 //	text := textSel.GetText(false)
 func (ts *TextSel) GetText(stripFormatting bool) string {
-	text := ts.text
-
 	if stripFormatting {
-		// Remove any format codes from the text
-		text = formatRegexGlobal.ReplaceAllString(text, "")
+		ts.getLines()
+		return ts.strippedText
 	}
 
-	return text
+	return ts.text
 }
 
 // SetText sets the text content of the TextSel widget, resetting the cursor
-// position and selection state.
+// position and selection state. Blocks are kept if the previous text was not
+// empty and the new displayed text starts with it, as when text is appended,
+// and removed otherwise.
 //
 // Example:
 //
 //	textSel.SetText("New text content")
 func (ts *TextSel) SetText(text string) *TextSel {
+	if previous := ts.GetText(true); previous == "" || !strings.HasPrefix(stripTags(text), previous) {
+		ts.blocks = nil
+	}
+
+	ts.storeText(text)
+	ts.source = nil
+	ts.ResetCursor()
+	return ts
+}
+
+// AppendText appends text, which may contain format codes, to the text
+// content of the TextSel widget, keeping the cursor position, the selection
+// and blocks, e.g. to add messages to a chat transcript as they arrive.
+//
+// If the text was set with SetMarkdown or SetANSIText, its source is kept:
+// GetSelectedTextAs and GetSelectedText with SetReturnSource return the
+// source of selections that lie entirely within the text set that way, and
+// the text as displayed for selections that include appended text.
+//
+// Example:
+//
+//	textSel.AppendText("\n[yellow]bot:[-] " + reply)
+func (ts *TextSel) AppendText(text string) *TextSel {
+	previous := ts.GetText(true)
+	ts.storeText(ts.text + text)
+
+	if ts.source != nil {
+		// The appended text has no source
+		if displayed := ts.GetText(true); strings.HasPrefix(displayed, previous) {
+			ts.source.skip(len(displayed) - len(previous))
+		} else {
+			ts.source = nil
+		}
+	}
+
+	ts.highlightCursor()
+	return ts
+}
+
+// Stores the text content, as the TextView stores it.
+func (ts *TextSel) storeText(text string) {
 	ts.TextView.SetText(text)
 	ts.text = ts.TextView.GetText(false)
-	ts.ResetCursor()
+	ts.lines = nil
+	ts.graphemeLines = nil
+	ts.words = nil
+}
+
+// SetUnhandledKeyFunc sets the callback function that will be called for key
+// events that are not bound in the keymap. The callback may return the event
+// (or a different one) to pass it on to the underlying TextView, which uses
+// some keys for scrolling and to call its done func, or nil to drop it.
+//
+// Use SetInputCapture to filter keys before the keymap sees them.
+//
+// Example:
+//
+//	textSel.SetUnhandledKeyFunc(func(event *tcell.EventKey) *tcell.EventKey {
+//		if event.Rune() == 'q' {
+//			app.Stop()
+//			return nil
+//		}
+//		return event
+//	})
+func (ts *TextSel) SetUnhandledKeyFunc(f func(event *tcell.EventKey) *tcell.EventKey) *TextSel {
+	ts.unhandledKeyFunc = f
 	return ts
 }
 
-// Handles key events for moving the cursor and selecting text.
-func (ts *TextSel) handleKeyEvents(event *tcell.EventKey) *tcell.EventKey {
-	switch event.Key() {
-	case tcell.KeyUp:
-		ts.MoveUp()
-	case tcell.KeyDown:
-		ts.MoveDown()
-	case tcell.KeyLeft:
-		ts.MoveLeft()
-	case tcell.KeyRight:
-		ts.MoveRight()
-	case tcell.KeyEnter:
-		ts.FinishSelection()
-	case tcell.KeyRune:
-		switch event.Rune() {
-		case ' ':
-			ts.StartSelection()
-		case 'k':
-			ts.MoveUp()
-		case 'j':
-			ts.MoveDown()
-		case 'h':
-			ts.MoveLeft()
-		case 'l':
-			ts.MoveRight()
-		case '^':
-			ts.MoveToStartOfLine()
-		case '$':
-			ts.MoveToEndOfLine()
+// SetInputCapture sets a function that receives every key before the keymap
+// does. It returns the key (or a different one) to handle it, or nil to drop
+// it.
+//
+// Example:
+//
+//	textSel.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+//		if event.Key() == tcell.KeyCtrlC {
+//			return nil
+//		}
+//		return event
+//	})
+func (ts *TextSel) SetInputCapture(capture func(event *tcell.EventKey) *tcell.EventKey) *TextSel {
+	ts.inputCapture = capture
+	return ts
+}
+
+// GetInputCapture returns the function set with SetInputCapture, or nil if
+// none has been set.
+func (ts *TextSel) GetInputCapture() func(event *tcell.EventKey) *tcell.EventKey {
+	return ts.inputCapture
+}
+
+// InputHandler returns the handler for this primitive. Keys pass through the
+// input capture first. Keys bound in the keymap are consumed. All others are
+// passed to the unhandled key callback and then to the underlying TextView.
+func (ts *TextSel) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		// The input capture is kept here rather than in the TextView, whose
+		// handler would apply it again to the keys passed on to it
+		if ts.inputCapture != nil {
+			event = ts.inputCapture(event)
+		}
+
+		if event != nil {
+			event = ts.handleKeyEvents(event)
 		}
+
+		if event != nil && ts.unhandledKeyFunc != nil {
+			event = ts.unhandledKeyFunc(event)
+		}
+
+		if event != nil {
+			ts.TextView.InputHandler()(event, setFocus)
+		}
+	}
+}
+
+// Draw draws this primitive onto the screen. If the widget has gained or lost
+// focus since the last draw, the cursor is shown or hidden. If the cursor has
+// moved, the view is first scrolled to make it visible.
+func (ts *TextSel) Draw(screen tcell.Screen) {
+	if focus := ts.HasFocus(); focus != ts.drawnFocus {
+		ts.drawnFocus = focus
+		ts.highlightCursor()
+	}
+
+	if ts.cursorRow != ts.drawnCursorRow || ts.cursorCol != ts.drawnCursorCol {
+		ts.drawnCursorRow = ts.cursorRow
+		ts.drawnCursorCol = ts.cursorCol
+		ts.scrollToCursor()
+	}
+
+	ts.TextView.Draw(screen)
+}
+
+// Handles key events for moving the cursor and selecting text, according to
+// the keymap. Returns nil if the event was consumed.
+func (ts *TextSel) handleKeyEvents(event *tcell.EventKey) *tcell.EventKey {
+	if ts.dispatchKey(event) {
+		return nil
 	}
 
 	return event
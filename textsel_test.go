@@ -2,6 +2,8 @@ package textsel
 
 import (
 	"testing"
+
+	"github.com/gdamore/tcell/v2"
 )
 
 func TestNewTextSel(t *testing.T) {
@@ -20,3 +22,133 @@ func TestGetAndSetText(t *testing.T) {
 		t.Errorf("GetText() returned the wrong text:\nExpected: '%v'\nGot: '%v'", "Hello, World!", got)
 	}
 }
+
+func TestInputHandler(t *testing.T) {
+	ts := NewTextSel().SetText("Hello\nWorld")
+	handler := ts.InputHandler()
+
+	// The host's input capture no longer replaces the key handling
+	captured := 0
+	ts.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		captured++
+		return event
+	})
+
+	handler(runeKey('j'), nil)
+
+	if row, _ := ts.GetCursorPosition(); row != 1 {
+		t.Errorf("InputHandler failed with an input capture set. Expected cursorRow = 1, got = %d", row)
+	}
+
+	// Unhandled keys reach the unhandled key callback and then the TextView
+	var unhandled []string
+	ts.SetUnhandledKeyFunc(func(event *tcell.EventKey) *tcell.EventKey {
+		unhandled = append(unhandled, KeyName(event))
+		return event
+	})
+
+	var done tcell.Key
+	ts.SetDoneFunc(func(key tcell.Key) { done = key })
+
+	handler(runeKey('k'), nil)
+	handler(tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone), nil)
+
+	if len(unhandled) != 1 || unhandled[0] != "Esc" {
+		t.Errorf("Unhandled key callback failed. Expected [Esc], got: %v", unhandled)
+	}
+
+	if done != tcell.KeyEsc {
+		t.Errorf("Unhandled key was not passed to the TextView")
+	}
+
+	if captured != 3 {
+		t.Errorf("Input capture should see each key exactly once. Expected 3 calls, got %d", captured)
+	}
+
+	// Dropping the key in the callback keeps it from the TextView
+	done = 0
+	ts.SetUnhandledKeyFunc(func(event *tcell.EventKey) *tcell.EventKey { return nil })
+	handler(tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone), nil)
+
+	if done != 0 {
+		t.Errorf("Dropped key was passed to the TextView")
+	}
+
+	// A filtering input capture keeps keys from the keymap
+	ts.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey { return nil })
+	handler(runeKey('j'), nil)
+
+	if row, _ := ts.GetCursorPosition(); row != 0 {
+		t.Errorf("Input capture failed to filter a key. Expected cursorRow = 0, got = %d", row)
+	}
+}
+
+func TestInputHandlerPassesKeysToTextView(t *testing.T) {
+	ts := NewTextSel().SetText("1\n2\n3\n4\n5\n6\n7\n8\n9\n10").SetKeymap(NewKeymap())
+	screen := tcell.NewSimulationScreen("UTF-8")
+	screen.Init()
+	screen.SetSize(10, 4)
+	ts.SetRect(0, 0, 10, 4)
+	ts.Draw(screen)
+	handler := ts.InputHandler()
+
+	// The TextView handles keys after the input capture, without applying it
+	// a second time
+	captured := 0
+	ts.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		captured++
+		return event
+	})
+
+	var done tcell.Key
+	ts.SetDoneFunc(func(key tcell.Key) { done = key })
+	handler(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), nil)
+
+	if done != tcell.KeyEnter || captured != 1 {
+		t.Errorf("InputHandler failed. Expected the done func called with Enter after 1 capture, got %v after %d", done, captured)
+	}
+
+	// Keys not bound in the keymap scroll the view
+	handler(runeKey('j'), nil)
+	handler(tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModNone), nil)
+
+	if row, _ := ts.GetScrollOffset(); row != 5 {
+		t.Errorf("InputHandler failed to scroll. Expected offset 5, got %d", row)
+	}
+
+	handler(runeKey('g'), nil)
+
+	if row, _ := ts.GetScrollOffset(); row != 0 {
+		t.Errorf("InputHandler failed to scroll to the top. Expected offset 0, got %d", row)
+	}
+
+	// Unless scrolling is turned off
+	ts.SetScrollable(false)
+	handler(runeKey('j'), nil)
+
+	if row, _ := ts.GetScrollOffset(); row != 0 {
+		t.Errorf("InputHandler scrolled with scrolling turned off. Expected offset 0, got %d", row)
+	}
+}
+
+func TestDrawShowsCursorOnFocus(t *testing.T) {
+	ts := NewTextSel().SetText("Hello")
+	screen := tcell.NewSimulationScreen("UTF-8")
+	screen.Init()
+	screen.SetSize(10, 2)
+	ts.SetRect(0, 0, 10, 2)
+
+	ts.Focus(nil)
+	ts.Draw(screen)
+
+	if got := ts.TextView.GetText(false); got != "[black:white:-]H[white:black:-:-]ello" {
+		t.Errorf("Draw failed to show the cursor after focus. Got '%s'", got)
+	}
+
+	ts.Blur()
+	ts.Draw(screen)
+
+	if got := ts.TextView.GetText(false); got != "Hello" {
+		t.Errorf("Draw failed to hide the cursor after blur. Got '%s'", got)
+	}
+}
@@ -0,0 +1,285 @@
+package textsel
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+// Character classes used to find word boundaries.
+const (
+	classSpace = iota
+	classKeyword
+	classPunct
+)
+
+// SetKeywordChars sets the characters that are part of words in addition to
+// letters and digits, like vim's `iskeyword` option. Keyword characters also
+// join the words on either side of them, so adding "/" makes a path a single
+// word, and "-" makes a kebab-case identifier a single word. The default is
+// "_".
+//
+// Example:
+//
+//	textSel.SetKeywordChars("_-/.")
+func (ts *TextSel) SetKeywordChars(chars string) *TextSel {
+	ts.keywordChars = chars
+	ts.words = nil
+	return ts
+}
+
+// GetKeywordChars returns the characters that are part of words in addition
+// to letters and digits.
+func (ts *TextSel) GetKeywordChars() string {
+	return ts.keywordChars
+}
+
+// Returns the character class of a character (a grapheme cluster), which is
+// determined by its first rune.
+func (ts *TextSel) charClass(char string) int {
+	r, _ := utf8.DecodeRuneInString(char)
+
+	switch {
+	case unicode.IsSpace(r):
+		return classSpace
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || ts.isKeywordChar(char):
+		return classKeyword
+	default:
+		return classPunct
+	}
+}
+
+// Returns true if a character is one of the configured keyword characters.
+func (ts *TextSel) isKeywordChar(char string) bool {
+	r, _ := utf8.DecodeRuneInString(char)
+	return strings.ContainsRune(ts.keywordChars, r)
+}
+
+// Splits the lines into words and returns, for each column of each line, the
+// index of the word it belongs to, or -1 for whitespace.
+//
+// A word is a run of keyword characters or a run of other non-blank
+// characters. Runs of keyword characters are further split at the word
+// boundaries of Unicode text segmentation (UAX #29), which separates e.g.
+// ideographs that are not separated by spaces. Configured keyword characters
+// join the words on either side of them.
+func (ts *TextSel) wordIndexes(lines [][]string) [][]int {
+	indexes := make([][]int, len(lines))
+	word := -1
+	prevClass := classSpace
+	prevJoins := false
+
+	for row, line := range lines {
+		indexes[row] = make([]int, 0, len(line))
+		text := strings.Join(line, "")
+		state := -1
+
+		for text != "" {
+			var segment string
+			segment, text, state = uniseg.FirstWordInString(text, state)
+
+			for i, char := range graphemes(segment) {
+				class := ts.charClass(char)
+				joins := ts.isKeywordChar(char)
+
+				if class == classSpace {
+					indexes[row] = append(indexes[row], -1)
+				} else {
+					segmentBoundary := i == 0 && class == classKeyword && !joins && !prevJoins
+
+					if class != prevClass || segmentBoundary {
+						word++
+					}
+
+					indexes[row] = append(indexes[row], word)
+				}
+
+				prevClass, prevJoins = class, joins
+			}
+		}
+	}
+
+	return indexes
+}
+
+// Returns the word indexes of the lines of the text (see wordIndexes), which
+// are cached until the text or the keyword characters change.
+func (ts *TextSel) getWords() [][]int {
+	if ts.words == nil {
+		ts.words = ts.wordIndexes(ts.getGraphemeLines())
+	}
+
+	return ts.words
+}
+
+// Steps through the text character by character across line boundaries.
+type textScanner struct {
+	lines [][]string
+	words [][]int
+	row   int
+	col   int
+}
+
+// Returns the character under the scanner.
+func (s *textScanner) char() string {
+	return s.lines[s.row][s.col]
+}
+
+// Returns the index of the word under the scanner, or -1 for whitespace (see
+// wordIndexes).
+func (s *textScanner) word() int {
+	return s.words[s.row][s.col]
+}
+
+// Moves to the next character. Returns false at the end of the text.
+func (s *textScanner) next() bool {
+	if s.col < len(s.lines[s.row])-1 {
+		s.col++
+		return true
+	}
+
+	if s.row < len(s.lines)-1 {
+		s.row++
+		s.col = 0
+		return true
+	}
+
+	return false
+}
+
+// Moves to the previous character. Returns false at the start of the text.
+func (s *textScanner) prev() bool {
+	if s.col > 0 {
+		s.col--
+		return true
+	}
+
+	if s.row > 0 {
+		s.row--
+		s.col = len(s.lines[s.row]) - 1
+		return true
+	}
+
+	return false
+}
+
+// Returns a scanner positioned at the cursor, or nil if the text is empty.
+func (ts *TextSel) scanner() *textScanner {
+	lines := ts.getGraphemeLines()
+
+	if ts.cursorRow >= len(lines) || ts.cursorCol >= len(lines[ts.cursorRow]) {
+		return nil
+	}
+
+	return &textScanner{lines: lines, words: ts.getWords(), row: ts.cursorRow, col: ts.cursorCol}
+}
+
+// Moves the cursor forward to the start of the next word.
+func (ts *TextSel) MoveWordForward() *TextSel {
+	s := ts.scanner()
+	if s == nil {
+		return ts
+	}
+
+	ok := true
+
+	if word := s.word(); word >= 0 {
+		for ok && s.word() == word {
+			ok = s.next()
+		}
+	}
+
+	for ok && s.word() < 0 {
+		ok = s.next()
+	}
+
+	return ts.SetCursorPosition(s.row, s.col)
+}
+
+// Moves the cursor backward to the start of the current or previous word.
+func (ts *TextSel) MoveWordBackward() *TextSel {
+	s := ts.scanner()
+	if s == nil || !s.prev() {
+		return ts
+	}
+
+	ok := true
+
+	for ok && s.word() < 0 {
+		ok = s.prev()
+	}
+
+	word := s.word()
+
+	for ok && s.word() == word {
+		ok = s.prev()
+	}
+
+	if ok {
+		s.next()
+	}
+
+	return ts.SetCursorPosition(s.row, s.col)
+}
+
+// Moves the cursor forward to the end of the current or next word.
+func (ts *TextSel) MoveWordEnd() *TextSel {
+	s := ts.scanner()
+	if s == nil || !s.next() {
+		return ts
+	}
+
+	ok := true
+
+	for ok && s.word() < 0 {
+		ok = s.next()
+	}
+
+	word := s.word()
+
+	for ok && s.word() == word {
+		ok = s.next()
+	}
+
+	if ok {
+		s.prev()
+	}
+
+	return ts.SetCursorPosition(s.row, s.col)
+}
+
+// SelectInnerWord selects the word under the cursor, or the run of whitespace
+// under the cursor, like vim's `iw` text object. A selection is started if
+// none is in progress. In ModePendingOperator, the pending operator is applied
+// to the word instead.
+func (ts *TextSel) SelectInnerWord() *TextSel {
+	s := ts.scanner()
+	if s == nil {
+		return ts
+	}
+
+	word := s.word()
+	first, last := *s, *s
+
+	for p := first; p.prev() && p.row == s.row && p.word() == word; {
+		first = p
+	}
+
+	for n := last; n.next() && n.row == s.row && n.word() == word; {
+		last = n
+	}
+
+	if ts.mode == ModePendingOperator {
+		ts.operatorRow, ts.operatorCol = first.row, first.col
+	} else {
+		if !ts.isSelecting() {
+			ts.StartSelection()
+		}
+
+		ts.selectionStartRow, ts.selectionStartCol = first.row, first.col
+	}
+
+	return ts.SetCursorPosition(last.row, last.col)
+}
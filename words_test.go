@@ -0,0 +1,187 @@
+package textsel
+
+import (
+	"testing"
+)
+
+func TestMoveWordForward(t *testing.T) {
+	ts := NewTextSel().SetText("foo.bar  baz\nqux")
+
+	expected := [][2]int{{0, 3}, {0, 4}, {0, 9}, {1, 0}, {1, 2}}
+
+	for _, pos := range expected {
+		ts.MoveWordForward()
+
+		row, col := ts.GetCursorPosition()
+		if row != pos[0] || col != pos[1] {
+			t.Errorf("MoveWordForward failed. Expected cursorRow = %d, cursorCol = %d, got = %d, %d", pos[0], pos[1], row, col)
+		}
+	}
+}
+
+func TestMoveWordBackward(t *testing.T) {
+	ts := NewTextSel().SetText("foo.bar  baz\nqux").SetCursorPosition(1, 2)
+
+	expected := [][2]int{{1, 0}, {0, 9}, {0, 4}, {0, 3}, {0, 0}, {0, 0}}
+
+	for _, pos := range expected {
+		ts.MoveWordBackward()
+
+		row, col := ts.GetCursorPosition()
+		if row != pos[0] || col != pos[1] {
+			t.Errorf("MoveWordBackward failed. Expected cursorRow = %d, cursorCol = %d, got = %d, %d", pos[0], pos[1], row, col)
+		}
+	}
+}
+
+func TestMoveWordEnd(t *testing.T) {
+	ts := NewTextSel().SetText("foo.bar  baz\nqux")
+
+	expected := [][2]int{{0, 2}, {0, 3}, {0, 6}, {0, 11}, {1, 2}, {1, 2}}
+
+	for _, pos := range expected {
+		ts.MoveWordEnd()
+
+		row, col := ts.GetCursorPosition()
+		if row != pos[0] || col != pos[1] {
+			t.Errorf("MoveWordEnd failed. Expected cursorRow = %d, cursorCol = %d, got = %d, %d", pos[0], pos[1], row, col)
+		}
+	}
+}
+
+func TestWordMotionsSelect(t *testing.T) {
+	ts := NewTextSel().SetText("Hello, World!")
+
+	ts.StartSelection().MoveWordEnd()
+
+	if got := ts.GetSelectedText(); got != "Hello" {
+		t.Errorf("Word motion failed to extend selection. Expected 'Hello', got: '%s'", got)
+	}
+}
+
+func TestUnicodeWords(t *testing.T) {
+	ts := NewTextSel().SetText("über café, naïve")
+
+	ts.MoveWordForward()
+
+	if _, col := ts.GetCursorPosition(); col != 5 {
+		t.Errorf("MoveWordForward over non-ASCII letters failed. Expected cursorCol = 5, got = %d", col)
+	}
+
+	ts.MoveWordEnd()
+
+	if _, col := ts.GetCursorPosition(); col != 8 {
+		t.Errorf("MoveWordEnd over non-ASCII letters failed. Expected cursorCol = 8, got = %d", col)
+	}
+}
+
+func TestWordIndexes(t *testing.T) {
+	ts := NewTextSel()
+
+	cases := []struct {
+		keywordChars string
+		text         string
+		expected     []int
+	}{
+		// Identifiers with underscores are one word, punctuation another
+		{"_", "foo_bar.baz", []int{0, 0, 0, 0, 0, 0, 0, 1, 2, 2, 2}},
+		// Ideographs are separate words, katakana runs are one word
+		{"_", "日本語 テキスト", []int{0, 1, 2, -1, 3, 3, 3, 3}},
+		// Paths are split at slashes unless they are keyword characters
+		{"_", "/usr/bin", []int{0, 1, 1, 1, 2, 3, 3, 3}},
+		{"_/", "/usr/bin", []int{0, 0, 0, 0, 0, 0, 0, 0}},
+	}
+
+	for _, c := range cases {
+		ts.SetKeywordChars(c.keywordChars)
+		got := ts.wordIndexes([][]string{graphemes(c.text)})[0]
+
+		if len(got) != len(c.expected) {
+			t.Errorf("wordIndexes(%q) failed. Expected %v, got %v", c.text, c.expected, got)
+			continue
+		}
+
+		for i := range c.expected {
+			if got[i] != c.expected[i] {
+				t.Errorf("wordIndexes(%q) failed. Expected %v, got %v", c.text, c.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestWordsFollowTextAndKeywordChars(t *testing.T) {
+	ts := NewTextSel().SetText("foo.bar baz")
+	ts.MoveWordForward()
+
+	if _, col := ts.GetCursorPosition(); col != 3 {
+		t.Errorf("MoveWordForward failed. Expected cursorCol = 3, got = %d", col)
+	}
+
+	// The cached words are dropped when the keyword characters change
+	ts.SetKeywordChars("_.").SetCursorPosition(0, 0).MoveWordForward()
+
+	if _, col := ts.GetCursorPosition(); col != 8 {
+		t.Errorf("MoveWordForward after SetKeywordChars failed. Expected cursorCol = 8, got = %d", col)
+	}
+
+	// And when the text changes
+	ts.SetText("a b.c").SetCursorPosition(0, 0).MoveWordForward()
+
+	if _, col := ts.GetCursorPosition(); col != 2 {
+		t.Errorf("MoveWordForward after SetText failed. Expected cursorCol = 2, got = %d", col)
+	}
+
+	ts.AppendText(" d").MoveWordForward()
+
+	if _, col := ts.GetCursorPosition(); col != 6 {
+		t.Errorf("MoveWordForward after AppendText failed. Expected cursorCol = 6, got = %d", col)
+	}
+}
+
+func TestMoveWordForwardCJK(t *testing.T) {
+	ts := NewTextSel().SetText("東京タワー")
+
+	expected := []int{1, 2, 4}
+
+	for _, col := range expected {
+		ts.MoveWordForward()
+
+		if _, got := ts.GetCursorPosition(); got != col {
+			t.Errorf("MoveWordForward in CJK text failed. Expected cursorCol = %d, got = %d", col, got)
+		}
+	}
+}
+
+func TestSelectInnerWord(t *testing.T) {
+	var selected string
+
+	ts := NewTextSel().SetKeymap(VimKeymap()).
+		SetText("cd /usr/local/bin && ls").
+		SetKeywordChars("_/").
+		SetSelectFunc(func(text string) { selected = text })
+
+	ts.SetCursorPosition(0, 8)
+
+	for _, r := range "yiw" {
+		ts.handleKeyEvents(runeKey(r))
+	}
+
+	if selected != "/usr/local/bin" {
+		t.Errorf("Yanking the inner word failed. Expected '/usr/local/bin', got '%s'", selected)
+	}
+
+	if row, col := ts.GetCursorPosition(); row != 0 || col != 3 {
+		t.Errorf("Yanking the inner word did not move the cursor to its start. Expected (0, 3), got (%d, %d)", row, col)
+	}
+
+	ts.SetCursorPosition(0, 18)
+
+	for _, r := range "viwy" {
+		ts.handleKeyEvents(runeKey(r))
+	}
+
+	if selected != "&&" {
+		t.Errorf("Selecting the inner word failed. Expected '&&', got '%s'", selected)
+	}
+}